@@ -0,0 +1,157 @@
+package awscache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/singleflight"
+)
+
+type options struct {
+	maxStale    time.Duration
+	loadTimeout time.Duration
+	now         func() time.Time
+}
+
+type Option func(*options)
+
+// How long after expiry a value is still returned when refreshing it fails, e.g. when throttled by SSM.
+// Defaults to 1 hour. Use 0 to never return expired values.
+func WithMaxStale(d time.Duration) Option {
+	return func(o *options) {
+		o.maxStale = d
+	}
+}
+
+// How long a load may take. Defaults to 30 seconds.
+// Loads are shared by concurrent callers, so they are not canceled with the context of the caller that started them, only by this timeout.
+func WithLoadTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.loadTimeout = d
+	}
+}
+
+// Counters of cache lookups since the cache was created.
+type Stats struct {
+	// Lookups returning an unexpired value
+	Hits uint64
+	// Lookups loading the value
+	Misses uint64
+	// Lookups returning an expired value since loading it failed
+	StaleHits uint64
+	// Loads that failed
+	Errors uint64
+}
+
+type entry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+// An in-process cache where values expire after a TTL.
+//
+// Concurrent misses for the same key share one load, and when loading fails an expired value is returned instead for up to `WithMaxStale`.
+// Errors are not cached. Cached values are shared between callers and must not be modified.
+//
+// Usage:
+// ```go
+//
+//	cache := awscache.New[string](5 * time.Minute)
+//	value, err := cache.Get(ctx, "key", func(ctx context.Context) (string, error) {
+//		return load(ctx)
+//	})
+//
+// ```
+type Cache[V any] struct {
+	ttl     time.Duration
+	options options
+
+	mu      sync.RWMutex
+	entries map[string]entry[V]
+	group   singleflight.Group
+
+	hits, misses, staleHits, errors atomic.Uint64
+}
+
+func New[V any](ttl time.Duration, opts ...Option) *Cache[V] {
+	o := options{maxStale: time.Hour, loadTimeout: 30 * time.Second, now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Cache[V]{ttl: ttl, options: o, entries: map[string]entry[V]{}}
+}
+
+// Returns the cached value for `key`, or calls `load` and caches its value if it's missing or expired.
+// If `ctx` is canceled while waiting for `load`, its error is returned while the load continues for the other callers.
+func (c *Cache[V]) Get(ctx context.Context, key string, load func(ctx context.Context) (V, error)) (V, error) {
+	c.mu.RLock()
+	cached, found := c.entries[key]
+	c.mu.RUnlock()
+
+	now := c.options.now()
+	if found && now.Before(cached.expires) {
+		c.hits.Add(1)
+		return cached.value, nil
+	}
+
+	c.misses.Add(1)
+	results := c.group.DoChan(key, func() (any, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.options.loadTimeout)
+		defer cancel()
+		value, err := load(ctx)
+		if err != nil {
+			return value, err
+		}
+		c.mu.Lock()
+		c.entries[key] = entry[V]{value: value, expires: c.options.now().Add(c.ttl)}
+		c.mu.Unlock()
+		return value, nil
+	})
+
+	var value any
+	var err error
+	select {
+	case result := <-results:
+		value, err = result.Val, result.Err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+	if err != nil {
+		c.errors.Add(1)
+		if found && now.Before(cached.expires.Add(c.options.maxStale)) {
+			c.staleHits.Add(1)
+			log.Warn().Err(err).Msgf("Failed to refresh cached value %s, using the value that expired at %s", key, cached.expires.Format(time.RFC3339))
+			return cached.value, nil
+		}
+		var zero V
+		return zero, err
+	}
+	return value.(V), nil
+}
+
+// Removes `key`, so the next `Get` loads it.
+func (c *Cache[V]) Invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// Removes all keys.
+func (c *Cache[V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+func (c *Cache[V]) Stats() Stats {
+	return Stats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		StaleHits: c.staleHits.Load(),
+		Errors:    c.errors.Load(),
+	}
+}
@@ -0,0 +1,165 @@
+package awscache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	awsssm "github.com/oslokommune/common-lib-go/aws/awsparameterstore"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) option() Option {
+	return func(o *options) {
+		o.now = func() time.Time { return c.now }
+	}
+}
+
+func TestCache_ExpiresAfterTTL(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	cache := New[int](time.Minute, clock.option())
+	loads := 0
+	load := func(ctx context.Context) (int, error) {
+		loads++
+		return loads, nil
+	}
+
+	first, _ := cache.Get(context.Background(), "key", load)
+	second, _ := cache.Get(context.Background(), "key", load)
+	clock.now = clock.now.Add(time.Minute)
+	third, _ := cache.Get(context.Background(), "key", load)
+
+	assert.Equal(t, []int{1, 1, 2}, []int{first, second, third})
+	assert.Equal(t, Stats{Hits: 1, Misses: 2}, cache.Stats())
+}
+
+func TestCache_ReturnsStaleValueWhenRefreshFails(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	cache := New[string](time.Minute, WithMaxStale(10*time.Minute), clock.option())
+	throttled := errors.New("ThrottlingException")
+
+	value, err := cache.Get(context.Background(), "key", func(ctx context.Context) (string, error) { return "cached", nil })
+	assert.NoError(t, err)
+	assert.Equal(t, "cached", value)
+
+	clock.now = clock.now.Add(5 * time.Minute)
+	value, err = cache.Get(context.Background(), "key", func(ctx context.Context) (string, error) { return "", throttled })
+	assert.NoError(t, err)
+	assert.Equal(t, "cached", value)
+
+	clock.now = clock.now.Add(10 * time.Minute)
+	_, err = cache.Get(context.Background(), "key", func(ctx context.Context) (string, error) { return "", throttled })
+	assert.ErrorIs(t, err, throttled)
+
+	assert.Equal(t, Stats{Misses: 3, StaleHits: 1, Errors: 2}, cache.Stats())
+}
+
+func TestCache_SharesConcurrentLoads(t *testing.T) {
+	cache := New[string](time.Minute)
+	var loads atomic.Int32
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := cache.Get(context.Background(), "key", func(ctx context.Context) (string, error) {
+				loads.Add(1)
+				<-release
+				return "value", nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, "value", value)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), loads.Load())
+}
+
+func TestCache_LoadOutlivesCanceledCaller(t *testing.T) {
+	cache := New[string](time.Minute)
+	release := make(chan struct{})
+	load := func(ctx context.Context) (string, error) {
+		select {
+		case <-release:
+			return "value", nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error)
+	go func() {
+		_, err := cache.Get(ctx, "key", load)
+		first <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	second := make(chan string)
+	go func() {
+		value, err := cache.Get(context.Background(), "key", load)
+		assert.NoError(t, err)
+		second <- value
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-first, context.Canceled)
+	close(release)
+	assert.Equal(t, "value", <-second)
+}
+
+func TestCache_TimesOutLoads(t *testing.T) {
+	cache := New[string](time.Minute, WithLoadTimeout(10*time.Millisecond))
+
+	_, err := cache.Get(context.Background(), "key", func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+type CountingParameterStoreMock struct {
+	calls atomic.Int32
+}
+
+func (m *CountingParameterStoreMock) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	m.calls.Add(1)
+	return &ssm.GetParameterOutput{Parameter: &types.Parameter{Name: params.Name, Value: aws.String("value of " + *params.Name)}}, nil
+}
+
+func TestParameterStoreClient_CachesByName(t *testing.T) {
+	mock := &CountingParameterStoreMock{}
+	client := NewParameterStoreClient(mock, time.Minute)
+
+	for range 3 {
+		value, err := awsssm.GetParameterStoreParameterString(context.Background(), client, "/app/a")
+		assert.NoError(t, err)
+		assert.Equal(t, "value of /app/a", *value)
+	}
+	value, err := awsssm.GetParameterStoreParameterString(context.Background(), client, "/app/b")
+	assert.NoError(t, err)
+	assert.Equal(t, "value of /app/b", *value)
+
+	assert.Equal(t, int32(2), mock.calls.Load())
+	assert.Equal(t, Stats{Hits: 2, Misses: 2}, client.Stats())
+
+	client.Clear()
+	_, _ = awsssm.GetParameterStoreParameterString(context.Background(), client, "/app/a")
+	assert.Equal(t, int32(3), mock.calls.Load())
+}
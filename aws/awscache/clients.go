@@ -0,0 +1,114 @@
+package awscache
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	awsssm "github.com/oslokommune/common-lib-go/aws/awsparameterstore"
+	"github.com/oslokommune/common-lib-go/aws/awssecretsmanager"
+)
+
+// Caches `GetParameter` calls by name and decryption.
+//
+// Usage:
+// ```go
+//
+//	client := awscache.NewParameterStoreClient(awsssm.NewClient(true), 5*time.Minute)
+//	value, err := awsssm.GetParameterStoreParameterString(ctx, client, "/my-app/config")
+//
+// ```
+type ParameterStoreClient struct {
+	client awsssm.GetParameterAPI
+	cached[*ssm.GetParameterOutput]
+}
+
+var _ awsssm.GetParameterAPI = (*ParameterStoreClient)(nil)
+
+func NewParameterStoreClient(client awsssm.GetParameterAPI, ttl time.Duration, opts ...Option) *ParameterStoreClient {
+	return &ParameterStoreClient{client, cached[*ssm.GetParameterOutput]{New[*ssm.GetParameterOutput](ttl, opts...)}}
+}
+
+func (c *ParameterStoreClient) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	key := cacheKey(aws.ToString(params.Name), strconv.FormatBool(aws.ToBool(params.WithDecryption)))
+	return c.cache.Get(ctx, key, func(ctx context.Context) (*ssm.GetParameterOutput, error) {
+		return c.client.GetParameter(ctx, params, optFns...)
+	})
+}
+
+// Caches `GetSecretValue` calls by secret ID, version ID and version stage.
+type SecretsManagerClient struct {
+	client awssecretsmanager.GetSecretValueApi
+	cached[*secretsmanager.GetSecretValueOutput]
+}
+
+var _ awssecretsmanager.GetSecretValueApi = (*SecretsManagerClient)(nil)
+
+func NewSecretsManagerClient(client awssecretsmanager.GetSecretValueApi, ttl time.Duration, opts ...Option) *SecretsManagerClient {
+	return &SecretsManagerClient{client, cached[*secretsmanager.GetSecretValueOutput]{New[*secretsmanager.GetSecretValueOutput](ttl, opts...)}}
+}
+
+func (c *SecretsManagerClient) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	key := cacheKey(aws.ToString(params.SecretId), aws.ToString(params.VersionId), aws.ToString(params.VersionStage))
+	return c.cache.Get(ctx, key, func(ctx context.Context) (*secretsmanager.GetSecretValueOutput, error) {
+		return c.client.GetSecretValue(ctx, params, optFns...)
+	})
+}
+
+// Caches parameters read from the Parameters and Secrets Lambda Extension by name and decryption.
+type ParameterStoreExtensionClient struct {
+	client awsssm.GetParmeterExtensionApi
+	cached[*string]
+}
+
+var _ awsssm.GetParmeterExtensionApi = (*ParameterStoreExtensionClient)(nil)
+
+func NewParameterStoreExtensionClient(client awsssm.GetParmeterExtensionApi, ttl time.Duration, opts ...Option) *ParameterStoreExtensionClient {
+	return &ParameterStoreExtensionClient{client, cached[*string]{New[*string](ttl, opts...)}}
+}
+
+func (c *ParameterStoreExtensionClient) GetParameter(ctx context.Context, name string, decrypt bool) (*string, error) {
+	return c.cache.Get(ctx, cacheKey(name, strconv.FormatBool(decrypt)), func(ctx context.Context) (*string, error) {
+		return c.client.GetParameter(ctx, name, decrypt)
+	})
+}
+
+// Caches secrets read from the Parameters and Secrets Lambda Extension by name and version.
+type SecretsManagerExtensionClient struct {
+	client awssecretsmanager.GetSecretFromExtensionApi
+	cached[*awssecretsmanager.SecretData]
+}
+
+var _ awssecretsmanager.GetSecretFromExtensionApi = (*SecretsManagerExtensionClient)(nil)
+
+func NewSecretsManagerExtensionClient(client awssecretsmanager.GetSecretFromExtensionApi, ttl time.Duration, opts ...Option) *SecretsManagerExtensionClient {
+	return &SecretsManagerExtensionClient{client, cached[*awssecretsmanager.SecretData]{New[*awssecretsmanager.SecretData](ttl, opts...)}}
+}
+
+func (c *SecretsManagerExtensionClient) GetSecret(ctx context.Context, name string, version *string) (*awssecretsmanager.SecretData, error) {
+	return c.cache.Get(ctx, cacheKey(name, aws.ToString(version)), func(ctx context.Context) (*awssecretsmanager.SecretData, error) {
+		return c.client.GetSecret(ctx, name, version)
+	})
+}
+
+// Exposes the stats of the cache in client wrappers, but not its keys.
+type cached[V any] struct {
+	cache *Cache[V]
+}
+
+func (c cached[V]) Stats() Stats {
+	return c.cache.Stats()
+}
+
+// Removes all cached values, e.g. after updating a parameter.
+func (c cached[V]) Clear() {
+	c.cache.Clear()
+}
+
+func cacheKey(parts ...string) string {
+	return strings.Join(parts, "\x00")
+}
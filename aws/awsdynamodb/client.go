@@ -2,12 +2,14 @@ package awsdynamodb
 
 import (
 	"context"
+	"errors"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/rs/zerolog/log"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-sdk-go-v2/otelaws"
 )
@@ -48,6 +50,24 @@ type DynamoDBQueryTableApi interface {
 		optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
 }
 
+type DynamoDBPutItemApi interface {
+	PutItem(ctx context.Context,
+		params *dynamodb.PutItemInput,
+		optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+}
+
+type DynamoDBGetItemApi interface {
+	GetItem(ctx context.Context,
+		params *dynamodb.GetItemInput,
+		optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+}
+
+type DynamoDBDeleteItemApi interface {
+	DeleteItem(ctx context.Context,
+		params *dynamodb.DeleteItemInput,
+		optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+}
+
 func describeTable(c context.Context, api DynamoDBDescribeTableAPI, input *dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
 	return api.DescribeTable(c, input)
 }
@@ -64,6 +84,107 @@ func queryTable(c context.Context, api DynamoDBQueryTableApi, input *dynamodb.Qu
 	return api.Query(c, input)
 }
 
+func putItem(c context.Context, api DynamoDBPutItemApi, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	return api.PutItem(c, input)
+}
+
+func getItem(c context.Context, api DynamoDBGetItemApi, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	return api.GetItem(c, input)
+}
+
+func deleteItem(c context.Context, api DynamoDBDeleteItemApi, input *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+	return api.DeleteItem(c, input)
+}
+
+// Writes `item` to the table, replacing any existing item with the same key.
+func PutTableItem(ctx context.Context, tablename string, client DynamoDBPutItemApi, item any) (*dynamodb.PutItemOutput, error) {
+	av, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return nil, err
+	}
+
+	input := dynamodb.PutItemInput{
+		TableName: aws.String(tablename),
+		Item:      av,
+	}
+
+	return putItem(ctx, client, &input)
+}
+
+// Writes `item` to the table if `condition` holds for the existing item.
+// Use `IsConditionalCheckFailed` to detect that the condition did not hold.
+func PutTableItemWithCondition(ctx context.Context, tablename string, client DynamoDBPutItemApi, item any, condition expression.ConditionBuilder) (*dynamodb.PutItemOutput, error) {
+	av, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return nil, err
+	}
+
+	expr, err := expression.NewBuilder().WithCondition(condition).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	input := dynamodb.PutItemInput{
+		TableName:                 aws.String(tablename),
+		Item:                      av,
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	return putItem(ctx, client, &input)
+}
+
+// Reads a single item by its primary key. Returns nil if no item exists.
+func GetTableItem[T any](ctx context.Context, tablename string, client DynamoDBGetItemApi, key map[string]any) (*T, error) {
+	pk, err := attributevalue.MarshalMap(key)
+	if err != nil {
+		return nil, err
+	}
+
+	input := dynamodb.GetItemInput{
+		TableName:      aws.String(tablename),
+		Key:            pk,
+		ConsistentRead: aws.Bool(true),
+	}
+
+	output, err := getItem(ctx, client, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	if output.Item == nil {
+		return nil, nil
+	}
+
+	var rec T
+	if err := attributevalue.UnmarshalMap(output.Item, &rec); err != nil {
+		return nil, err
+	}
+
+	return &rec, nil
+}
+
+func DeleteTableItem(ctx context.Context, tablename string, client DynamoDBDeleteItemApi, key map[string]any) (*dynamodb.DeleteItemOutput, error) {
+	pk, err := attributevalue.MarshalMap(key)
+	if err != nil {
+		return nil, err
+	}
+
+	input := dynamodb.DeleteItemInput{
+		TableName: aws.String(tablename),
+		Key:       pk,
+	}
+
+	return deleteItem(ctx, client, &input)
+}
+
+// Reports whether `err` is caused by a condition expression that did not hold.
+func IsConditionalCheckFailed(err error) bool {
+	var ccf *types.ConditionalCheckFailedException
+	return errors.As(err, &ccf)
+}
+
 func UpdateTableItem(ctx context.Context, tablename string, client DynamoDBUpdateItemApi, key map[string]any, values map[string]any) (*dynamodb.UpdateItemOutput, error) {
 	pk, err := attributevalue.MarshalMap(key)
 	if err != nil {
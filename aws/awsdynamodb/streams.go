@@ -0,0 +1,50 @@
+package awsdynamodb
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Unmarshals a DynamoDB Streams image, e.g. `record.Change.NewImage`, into `out` using the same `dynamodbav` tags as the table helpers.
+func UnmarshalStreamImage(image map[string]events.DynamoDBAttributeValue, out any) error {
+	return attributevalue.UnmarshalMap(FromStreamImage(image), out)
+}
+
+// Converts a DynamoDB Streams image from a Lambda event to SDK attribute values.
+func FromStreamImage(image map[string]events.DynamoDBAttributeValue) map[string]types.AttributeValue {
+	item := make(map[string]types.AttributeValue, len(image))
+	for name, value := range image {
+		item[name] = fromStreamAttributeValue(value)
+	}
+	return item
+}
+
+func fromStreamAttributeValue(value events.DynamoDBAttributeValue) types.AttributeValue {
+	switch value.DataType() {
+	case events.DataTypeString:
+		return &types.AttributeValueMemberS{Value: value.String()}
+	case events.DataTypeNumber:
+		return &types.AttributeValueMemberN{Value: value.Number()}
+	case events.DataTypeBinary:
+		return &types.AttributeValueMemberB{Value: value.Binary()}
+	case events.DataTypeBoolean:
+		return &types.AttributeValueMemberBOOL{Value: value.Boolean()}
+	case events.DataTypeStringSet:
+		return &types.AttributeValueMemberSS{Value: value.StringSet()}
+	case events.DataTypeNumberSet:
+		return &types.AttributeValueMemberNS{Value: value.NumberSet()}
+	case events.DataTypeBinarySet:
+		return &types.AttributeValueMemberBS{Value: value.BinarySet()}
+	case events.DataTypeList:
+		list := make([]types.AttributeValue, len(value.List()))
+		for i, v := range value.List() {
+			list[i] = fromStreamAttributeValue(v)
+		}
+		return &types.AttributeValueMemberL{Value: list}
+	case events.DataTypeMap:
+		return &types.AttributeValueMemberM{Value: FromStreamImage(value.Map())}
+	default:
+		return &types.AttributeValueMemberNULL{Value: true}
+	}
+}
@@ -3,6 +3,9 @@ package awsssm
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
 
 	//"fmt"
 
@@ -43,6 +46,18 @@ type DescribeParametersApi interface {
 		optFns ...func(*ssm.Options)) (*ssm.DescribeParametersOutput, error)
 }
 
+type GetParametersApi interface {
+	GetParameters(ctx context.Context,
+		params *ssm.GetParametersInput,
+		optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error)
+}
+
+type GetParametersByPathApi interface {
+	GetParametersByPath(ctx context.Context,
+		params *ssm.GetParametersByPathInput,
+		optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error)
+}
+
 func getParameter(ctx context.Context, api GetParameterAPI, input *ssm.GetParameterInput) (*ssm.GetParameterOutput, error) {
 	return api.GetParameter(ctx, input)
 }
@@ -114,3 +129,82 @@ func DescribeParameterStoreParameter(ctx context.Context, client DescribeParamet
 
 	return describeParameters(ctx, client, &input)
 }
+
+// Options for reading parameters by path.
+type PathOption func(*ssm.GetParametersByPathInput)
+
+// Only reads the parameters directly below the path.
+func NonRecursive() PathOption {
+	return func(input *ssm.GetParametersByPathInput) {
+		input.Recursive = aws.Bool(false)
+	}
+}
+
+// Returns `SecureString` parameters encrypted.
+func WithoutDecryption() PathOption {
+	return func(input *ssm.GetParametersByPathInput) {
+		input.WithDecryption = aws.Bool(false)
+	}
+}
+
+// Only reads parameters matching `filters`, e.g. by `Type` or `Label`.
+func WithParameterFilters(filters ...types.ParameterStringFilter) PathOption {
+	return func(input *ssm.GetParametersByPathInput) {
+		input.ParameterFilters = append(input.ParameterFilters, filters...)
+	}
+}
+
+// Reads all parameters below `path`, recursively and decrypted unless changed by `options`, following all pages.
+// Returns the values keyed by the full parameter names, e.g. `/app/prod/db/host`.
+func GetParameterStoreParametersByPath(ctx context.Context, client GetParametersByPathApi, path string, options ...PathOption) (map[string]string, error) {
+	input := ssm.GetParametersByPathInput{
+		Path:           aws.String(path),
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(true),
+	}
+	for _, option := range options {
+		option(&input)
+	}
+
+	parameters := map[string]string{}
+	paginator := ssm.NewGetParametersByPathPaginator(client, &input)
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, parameter := range output.Parameters {
+			parameters[aws.ToString(parameter.Name)] = aws.ToString(parameter.Value)
+		}
+	}
+
+	return parameters, nil
+}
+
+// Maximum number of names in one `GetParameters` call.
+const maxParametersPerCall = 10
+
+// Reads the parameters `names`, decrypted, in as few calls as possible.
+// Returns the values keyed by name. If some parameters don't exist, the values found are returned with an error wrapping `ErrParameterNotFound`.
+func GetParameterStoreParameters(ctx context.Context, client GetParametersApi, names []string) (map[string]string, error) {
+	parameters := make(map[string]string, len(names))
+	var invalid []string
+	for batch := range slices.Chunk(names, maxParametersPerCall) {
+		output, err := client.GetParameters(ctx, &ssm.GetParametersInput{
+			Names:          batch,
+			WithDecryption: aws.Bool(true),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, parameter := range output.Parameters {
+			parameters[aws.ToString(parameter.Name)] = aws.ToString(parameter.Value)
+		}
+		invalid = append(invalid, output.InvalidParameters...)
+	}
+
+	if len(invalid) > 0 {
+		return parameters, fmt.Errorf("%w: %s", ErrParameterNotFound, strings.Join(invalid, ", "))
+	}
+	return parameters, nil
+}
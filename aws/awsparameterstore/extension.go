@@ -3,64 +3,238 @@ package awsssm
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultExtensionPort    = "2773"
+	defaultExtensionTimeout = 10 * time.Second
+	defaultStartupRetries   = 5
+	startupRetryDelay       = 100 * time.Millisecond
+)
+
+var (
+	// The extension rejected the request, e.g. an invalid parameter name
+	ErrInvalidRequest = errors.New("invalid request")
+	// The Lambda role can't read the parameter, or the session token is missing
+	ErrAccessDenied = errors.New("access denied")
+	// The parameter doesn't exist, or some of the parameters read by `GetParameterStoreParameters` don't
+	ErrParameterNotFound = errors.New("parameter not found")
 )
 
-var sessionToken = os.Getenv("AWS_SESSION_TOKEN")
+// An error response from the Parameters and Secrets Lambda Extension.
+// Use `errors.Is` with `ErrInvalidRequest`, `ErrAccessDenied` or `ErrParameterNotFound` to check the cause.
+type ExtensionError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *ExtensionError) Error() string {
+	return fmt.Sprintf("parameters and secrets extension responded with status code %d: %s", e.StatusCode, e.Message)
+}
+
+func (e *ExtensionError) Is(target error) bool {
+	switch target {
+	case ErrInvalidRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrAccessDenied:
+		return e.StatusCode == http.StatusForbidden
+	case ErrParameterNotFound:
+		return e.StatusCode == http.StatusNotFound
+	default:
+		return false
+	}
+}
 
 type GetParmeterExtensionApi interface {
 	GetParameter(ctx context.Context, name string, decrypt bool) (*string, error)
 }
 
-type ParameterStoreExtensionClient struct{}
+// A parameter as returned by the extension.
+type ParameterData struct {
+	ARN              string    `json:"ARN"`
+	Name             string    `json:"Name"`
+	Type             string    `json:"Type"`
+	Value            string    `json:"Value"`
+	Version          int64     `json:"Version"`
+	DataType         string    `json:"DataType"`
+	Selector         string    `json:"Selector"`
+	SourceResult     string    `json:"SourceResult"`
+	LastModifiedDate time.Time `json:"LastModifiedDate"`
+}
+
+type parameterResponse struct {
+	Parameter *ParameterData `json:"Parameter"`
+}
 
-func (p *ParameterStoreExtensionClient) GetParameter(ctx context.Context, name string, decrypt bool) (*string, error) {
-	// Define the URL
-	url := fmt.Sprintf("http://localhost:2773/systemsmanager/parameters/get?name=%s&withDecryption=%s", url.QueryEscape(name), strconv.FormatBool(decrypt))
+// Reads parameters from the Parameters and Secrets Lambda Extension.
+//
+// The zero value is usable, with the defaults of `NewExtensionClient` and without tracing.
+type ParameterStoreExtensionClient struct {
+	httpClient     *http.Client
+	port           string
+	startupRetries int
+}
+
+var _ GetParmeterExtensionApi = (*ParameterStoreExtensionClient)(nil)
+
+// Used by the zero value of `ParameterStoreExtensionClient`.
+// Created on first use, so the port is read from the environment when it's set up, not when the package is initialized.
+var defaultExtensionClient = sync.OnceValue(func() *ParameterStoreExtensionClient {
+	return NewExtensionClient(false)
+})
+
+type ExtensionOption func(*ParameterStoreExtensionClient)
+
+// Timeout of each request to the extension. Defaults to 10 seconds.
+func WithTimeout(timeout time.Duration) ExtensionOption {
+	return func(p *ParameterStoreExtensionClient) {
+		p.httpClient.Timeout = timeout
+	}
+}
+
+// Port of the extension. Defaults to `PARAMETERS_SECRETS_EXTENSION_HTTP_PORT`, or 2773 if not set.
+func WithPort(port string) ExtensionOption {
+	return func(p *ParameterStoreExtensionClient) {
+		p.port = port
+	}
+}
+
+// How many times a request is retried while the extension is starting. Defaults to 5.
+func WithStartupRetries(retries int) ExtensionOption {
+	return func(p *ParameterStoreExtensionClient) {
+		p.startupRetries = retries
+	}
+}
+
+func NewExtensionClient(tracing bool, options ...ExtensionOption) *ParameterStoreExtensionClient {
+	httpClient := &http.Client{
+		Timeout: defaultExtensionTimeout,
+	}
+
+	if tracing {
+		commonLabels := []attribute.KeyValue{
+			attribute.String("otel.resource.service.name", "parameter store extension client"),
+		}
 
-	// Create an HTTP GET request
-	client := http.Client{}
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		httpClient.Transport = otelhttp.NewTransport(http.DefaultTransport, otelhttp.WithSpanOptions(trace.WithAttributes(commonLabels...)))
+	}
+
+	client := &ParameterStoreExtensionClient{
+		httpClient:     httpClient,
+		port:           extensionPort(),
+		startupRetries: defaultStartupRetries,
+	}
+	for _, option := range options {
+		option(client)
+	}
+	return client
+}
+
+// Returns the value of the parameter `name`, see `GetParameterData`.
+func (p *ParameterStoreExtensionClient) GetParameter(ctx context.Context, name string, decrypt bool) (*string, error) {
+	parameter, err := p.GetParameterData(ctx, name, decrypt)
 	if err != nil {
 		return nil, err
 	}
+	return &parameter.Value, nil
+}
 
-	req.Header.Add("X-Aws-Parameters-Secrets-Token", sessionToken)
+// Returns the parameter `name` with its version, type and ARN.
+// Requests are retried while the extension is starting, and error responses are returned as `ExtensionError`.
+func (p *ParameterStoreExtensionClient) GetParameterData(ctx context.Context, name string, decrypt bool) (*ParameterData, error) {
+	if p.httpClient == nil {
+		return defaultExtensionClient().GetParameterData(ctx, name, decrypt)
+	}
+
+	query := url.Values{}
+	query.Set("name", name)
+	query.Set("withDecryption", strconv.FormatBool(decrypt))
+	endpoint := fmt.Sprintf("http://localhost:%s/systemsmanager/parameters/get?%s", p.port, query.Encode())
 
-	// Call endpoint
-	response, err := client.Do(req)
+	var responseBody []byte
+	var err error
+	for attempt := 0; ; attempt++ {
+		responseBody, err = p.get(ctx, endpoint)
+		if err == nil || attempt >= p.startupRetries || !isStarting(err) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(startupRetryDelay * time.Duration(attempt+1)):
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
-	defer response.Body.Close()
 
-	// Check if the response status code is not OK (200)
-	if response.StatusCode != http.StatusOK {
-		text, _ := io.ReadAll(response.Body)
-		return nil, fmt.Errorf("HTTP request failed with status code %d. Text: %s", response.StatusCode, text)
+	var response parameterResponse
+	if err := json.Unmarshal(responseBody, &response); err != nil {
+		return nil, fmt.Errorf("unexpected response from parameters and secrets extension: %w", err)
+	}
+	if response.Parameter == nil {
+		return nil, fmt.Errorf("unexpected response from parameters and secrets extension: missing Parameter")
 	}
 
-	// Read the response body
-	responseBody, err := io.ReadAll(response.Body)
+	return response.Parameter, nil
+}
+
+func (p *ParameterStoreExtensionClient) get(ctx context.Context, endpoint string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var container map[string]any
-	err = json.Unmarshal(responseBody, &container)
+	// Read for each request, since the token changes when SnapStart restores the function
+	req.Header.Add("X-Aws-Parameters-Secrets-Token", os.Getenv("AWS_SESSION_TOKEN"))
+
+	response, err := p.httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
+	defer response.Body.Close()
 
-	value := container["Parameter"].(map[string]any)
-	valueString := value["Value"].(string)
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
 
-	return &valueString, nil
+	if response.StatusCode != http.StatusOK {
+		return nil, &ExtensionError{StatusCode: response.StatusCode, Message: strings.TrimSpace(string(body))}
+	}
+	return body, nil
+}
+
+// The extension refuses connections until it's started, and then responds that it's not ready until it's initialized.
+func isStarting(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var extensionError *ExtensionError
+	return errors.As(err, &extensionError) && strings.Contains(extensionError.Message, "not ready")
+}
+
+func extensionPort() string {
+	if port := os.Getenv("PARAMETERS_SECRETS_EXTENSION_HTTP_PORT"); port != "" {
+		return port
+	}
+	return defaultExtensionPort
 }
 
 func ReadParameterStoreParameterFromExtension(ctx context.Context, name string, api GetParmeterExtensionApi, decrypt bool) (*string, error) {
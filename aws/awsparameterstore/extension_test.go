@@ -0,0 +1,119 @@
+package awsssm
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newExtensionServer(t *testing.T, handler http.HandlerFunc) *ParameterStoreExtensionClient {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	u, _ := url.Parse(server.URL)
+	return NewExtensionClient(false, WithPort(u.Port()))
+}
+
+func TestParameterStoreExtensionClient_ReturnsTypedParameter(t *testing.T) {
+	t.Setenv("AWS_SESSION_TOKEN", "token")
+	client := newExtensionServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/systemsmanager/parameters/get", r.URL.Path)
+		assert.Equal(t, "/app/db password", r.URL.Query().Get("name"))
+		assert.Equal(t, "true", r.URL.Query().Get("withDecryption"))
+		assert.Equal(t, "token", r.Header.Get("X-Aws-Parameters-Secrets-Token"))
+		_, _ = w.Write([]byte(`{"Parameter":{"ARN":"arn:aws:ssm:eu-north-1:123456789012:parameter/app/db","Name":"/app/db password","Type":"SecureString","Value":"hunter2","Version":3}}`))
+	})
+
+	parameter, err := client.GetParameterData(context.Background(), "/app/db password", true)
+	assert.NoError(t, err)
+	assert.Equal(t, ParameterData{
+		ARN:     "arn:aws:ssm:eu-north-1:123456789012:parameter/app/db",
+		Name:    "/app/db password",
+		Type:    "SecureString",
+		Value:   "hunter2",
+		Version: 3,
+	}, *parameter)
+
+	value, err := ReadParameterStoreParameterFromExtension(context.Background(), "/app/db password", client, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", *value)
+}
+
+func TestParameterStoreExtensionClient_ReturnsTypedErrors(t *testing.T) {
+	for status, expected := range map[int]error{
+		http.StatusBadRequest: ErrInvalidRequest,
+		http.StatusForbidden:  ErrAccessDenied,
+		http.StatusNotFound:   ErrParameterNotFound,
+	} {
+		client := newExtensionServer(t, func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "failed", status)
+		})
+
+		_, err := client.GetParameter(context.Background(), "/app/missing", false)
+
+		assert.ErrorIs(t, err, expected)
+		var extensionError *ExtensionError
+		assert.ErrorAs(t, err, &extensionError)
+		assert.Equal(t, ExtensionError{StatusCode: status, Message: "failed"}, *extensionError)
+	}
+}
+
+func TestParameterStoreExtensionClient_RejectsUnexpectedResponse(t *testing.T) {
+	client := newExtensionServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Parameter":"value"}`))
+	})
+
+	_, err := client.GetParameter(context.Background(), "/app/name", false)
+
+	assert.ErrorContains(t, err, "unexpected response from parameters and secrets extension")
+}
+
+func TestParameterStoreExtensionClient_RetriesWhileStarting(t *testing.T) {
+	requests := 0
+	client := newExtensionServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			http.Error(w, "not ready to serve traffic, please wait", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"Parameter":{"Name":"/app/name","Value":"my-app"}}`))
+	})
+
+	value, err := client.GetParameter(context.Background(), "/app/name", false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "my-app", *value)
+	assert.Equal(t, 3, requests)
+}
+
+func TestParameterStoreExtensionClient_RetriesRefusedConnections(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+
+	client := NewExtensionClient(false, WithPort(port), WithStartupRetries(2))
+	_, err = client.GetParameter(context.Background(), "/app/name", false)
+
+	assert.ErrorContains(t, err, "connection refused")
+}
+
+func TestParameterStoreExtensionClient_ZeroValueReadsPortWhenUsed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Parameter":{"Name":"/app/name","Value":"value"}}`))
+	}))
+	t.Cleanup(server.Close)
+	u, _ := url.Parse(server.URL)
+	t.Setenv("PARAMETERS_SECRETS_EXTENSION_HTTP_PORT", u.Port())
+
+	var client ParameterStoreExtensionClient
+	value, err := client.GetParameter(context.Background(), "/app/name", false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "value", *value)
+}
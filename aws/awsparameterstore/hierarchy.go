@@ -0,0 +1,84 @@
+package awsssm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/oslokommune/common-lib-go/aws/internal/decode"
+)
+
+type hierarchyParameter struct {
+	name  string
+	value string
+}
+
+// Reads the parameters below `path` into the struct `container` points to, one parameter per field.
+//
+// Fields are named by their `ssm` tag, `json` tag or field name, matched ignoring case, and nested structs are sub paths.
+// Maps get the parameters below their path, keyed by the rest of the name.
+// Values are decoded like in `configurationreader.Load`, e.g. `8080` for an `int` and `5s` for a `time.Duration`.
+// Parameters without a field are ignored, and fields without a parameter are left as is.
+//
+// Usage:
+// ```go
+//
+//	type Config struct {
+//		Database struct {
+//			Host    string        `ssm:"host"`
+//			Port    int           `ssm:"port"`
+//			Timeout time.Duration `ssm:"timeout"`
+//		} `ssm:"db"`
+//		Features map[string]bool `ssm:"features"`
+//	}
+//
+//	// Reads /app/prod/db/host, /app/prod/db/port, /app/prod/db/timeout and /app/prod/features/*
+//	var cfg Config
+//	err := awsssm.GetParameterStoreHierarchy(ctx, client, "/app/prod", &cfg)
+//
+// ```
+func GetParameterStoreHierarchy(ctx context.Context, client GetParametersByPathApi, path string, container any, options ...PathOption) error {
+	v := reflect.ValueOf(container)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("container must be a pointer to a struct, got %T", container)
+	}
+
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	values, err := GetParameterStoreParametersByPath(ctx, client, prefix, options...)
+	if err != nil {
+		return err
+	}
+
+	parameters := make(map[string]hierarchyParameter, len(values))
+	for name, value := range values {
+		parameters[strings.ToLower(strings.TrimPrefix(name, prefix))] = hierarchyParameter{name, value}
+	}
+
+	var errs []error
+	for _, field := range decode.Fields(v.Elem(), "ssm", "json") {
+		name := field.Key("/", "ssm", "json")
+		key := strings.ToLower(name)
+		if field.Type().Kind() == reflect.Map {
+			entries := map[string]string{}
+			for k, parameter := range parameters {
+				if strings.HasPrefix(k, key+"/") {
+					entries[parameter.name[len(prefix)+len(key)+1:]] = parameter.value
+				}
+			}
+			if len(entries) > 0 {
+				if err := decode.Map(field.Value, entries); err != nil {
+					errs = append(errs, fmt.Errorf("%s%s: %w", prefix, name, err))
+				}
+			}
+			continue
+		}
+		if parameter, ok := parameters[key]; ok {
+			if err := decode.Value(field.Value, parameter.value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid value: %w", parameter.name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,132 @@
+package awsssm
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// Serves `parameters` two at a time, like a Parameter Store with a small page size.
+type ParameterStoreMock struct {
+	parameters map[string]string
+	calls      [][]string
+	inputs     []*ssm.GetParametersByPathInput
+}
+
+func (m *ParameterStoreMock) GetParameters(ctx context.Context, params *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error) {
+	m.calls = append(m.calls, params.Names)
+	output := &ssm.GetParametersOutput{}
+	for _, name := range params.Names {
+		if value, ok := m.parameters[name]; ok {
+			output.Parameters = append(output.Parameters, types.Parameter{Name: aws.String(name), Value: aws.String(value)})
+		} else {
+			output.InvalidParameters = append(output.InvalidParameters, name)
+		}
+	}
+	return output, nil
+}
+
+func (m *ParameterStoreMock) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	m.inputs = append(m.inputs, params)
+
+	var matching []types.Parameter
+	for _, name := range slices.Sorted(maps.Keys(m.parameters)) {
+		rest, found := strings.CutPrefix(name, *params.Path)
+		if found && (*params.Recursive || !strings.Contains(rest, "/")) {
+			matching = append(matching, types.Parameter{Name: aws.String(name), Value: aws.String(m.parameters[name])})
+		}
+	}
+
+	start := 0
+	if params.NextToken != nil {
+		start = int((*params.NextToken)[0] - '0')
+	}
+	end := min(start+2, len(matching))
+	output := &ssm.GetParametersByPathOutput{Parameters: matching[start:end]}
+	if end < len(matching) {
+		output.NextToken = aws.String(string(rune('0' + end)))
+	}
+	return output, nil
+}
+
+func TestGetParameterStoreParameters_BatchesNames(t *testing.T) {
+	mock := &ParameterStoreMock{parameters: map[string]string{}}
+	var names []string
+	for i := range 23 {
+		name := "/app/" + string(rune('a'+i))
+		mock.parameters[name] = "value"
+		names = append(names, name)
+	}
+	names = append(names, "/app/missing")
+
+	values, err := GetParameterStoreParameters(context.Background(), mock, names)
+
+	assert.ErrorIs(t, err, ErrParameterNotFound)
+	assert.ErrorContains(t, err, "/app/missing")
+	assert.Equal(t, 23, len(values))
+	assert.Equal(t, []int{10, 10, 4}, []int{len(mock.calls[0]), len(mock.calls[1]), len(mock.calls[2])})
+}
+
+type DatabaseConfig struct {
+	Host    string        `ssm:"host"`
+	Port    int           `ssm:"port"`
+	Timeout time.Duration `json:"timeout"`
+}
+
+type HierarchyConfig struct {
+	Name     string          `json:"name"`
+	Database *DatabaseConfig `ssm:"db"`
+	Features map[string]bool `ssm:"features"`
+	Ignored  string          `ssm:"-"`
+}
+
+func TestGetParameterStoreHierarchy_MapsPathsOntoStruct(t *testing.T) {
+	mock := &ParameterStoreMock{parameters: map[string]string{
+		"/app/prod/Name":            "my-app",
+		"/app/prod/db/host":         "db.internal",
+		"/app/prod/db/port":         "5432",
+		"/app/prod/db/timeout":      "5s",
+		"/app/prod/features/search": "true",
+		"/app/prod/features/export": "false",
+		"/app/prod/Ignored":         "value",
+		"/app/prod/unknown":         "value",
+		"/app/test/name":            "other-app",
+	}}
+
+	var cfg HierarchyConfig
+	err := GetParameterStoreHierarchy(context.Background(), mock, "/app/prod", &cfg)
+
+	assert.NoError(t, err)
+	assert.Equal(t, HierarchyConfig{
+		Name:     "my-app",
+		Database: &DatabaseConfig{Host: "db.internal", Port: 5432, Timeout: 5 * time.Second},
+		Features: map[string]bool{"search": true, "export": false},
+	}, cfg)
+	assert.Equal(t, 4, len(mock.inputs))
+}
+
+func TestGetParameterStoreHierarchy_ReturnsAllInvalidValues(t *testing.T) {
+	mock := &ParameterStoreMock{parameters: map[string]string{
+		"/app/prod/db/port":         "many",
+		"/app/prod/db/timeout":      "soon",
+		"/app/prod/features/search": "maybe",
+	}}
+
+	var cfg HierarchyConfig
+	err := GetParameterStoreHierarchy(context.Background(), mock, "/app/prod/", &cfg, NonRecursive())
+	assert.NoError(t, err)
+	assert.False(t, *mock.inputs[0].Recursive)
+
+	err = GetParameterStoreHierarchy(context.Background(), mock, "/app/prod/", &cfg)
+	assert.ErrorContains(t, err, "/app/prod/db/port: invalid value")
+	assert.ErrorContains(t, err, "/app/prod/db/timeout: invalid value")
+	assert.ErrorContains(t, err, "/app/prod/features: key \"search\"")
+}
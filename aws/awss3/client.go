@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"iter"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -16,8 +17,13 @@ import (
 )
 
 type S3File struct {
-	CreatedAt time.Time
-	Name      string
+	CreatedAt    time.Time
+	Name         string
+	Size         int64
+	ETag         string
+	StorageClass string
+	// A common prefix, like a folder, when listing with a delimiter. Only `Name` is set.
+	IsPrefix bool
 }
 
 func NewClient(useTracing bool) *s3.Client {
@@ -45,10 +51,6 @@ type PresignObject interface {
 	PresignGetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error)
 }
 
-func listObjects(ctx context.Context, api ListObjectsV2API, params *s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error) {
-	return api.ListObjectsV2(ctx, params)
-}
-
 func getObject(ctx context.Context, api GetObjectAPI, params *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
 	return api.GetObject(ctx, params)
 }
@@ -67,24 +69,117 @@ func getObjectWithManager(ctx context.Context, api GetObjectAPI, params *s3.GetO
 	return err
 }
 
-func ListBucketObjects(ctx context.Context, client ListObjectsV2API, bucketName string, prefix string) ([]S3File, error) {
+type listOptions struct {
+	delimiter  string
+	startAfter string
+	pageSize   int32
+}
+
+type ListOption func(*listOptions)
+
+// Groups keys containing `delimiter` after the prefix into common prefixes, like folders, e.g. "/".
+func WithDelimiter(delimiter string) ListOption {
+	return func(o *listOptions) {
+		o.delimiter = delimiter
+	}
+}
+
+// Starts listing after `key`, e.g. the last name of a previous listing to resume it, also when it is a common prefix.
+func WithStartAfter(key string) ListOption {
+	return func(o *listOptions) {
+		o.startAfter = key
+	}
+}
+
+// Maximum number of keys per `ListObjectsV2` call. Defaults to 1000.
+func WithPageSize(size int32) ListOption {
+	return func(o *listOptions) {
+		o.pageSize = size
+	}
+}
+
+// ListObjects lists all objects below `prefix` in lexicographical order, fetching the pages lazily.
+// With a delimiter, the common prefixes are yielded in order among the objects, with `IsPrefix` set.
+//
+// Usage:
+// ```go
+//
+//	for file, err := range awss3.ListObjects(ctx, client, "my-bucket", "reports/", awss3.WithDelimiter("/")) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(file.Name, file.IsPrefix)
+//	}
+//
+// ```
+func ListObjects(ctx context.Context, client ListObjectsV2API, bucketName string, prefix string, options ...ListOption) iter.Seq2[S3File, error] {
+	o := listOptions{}
+	for _, option := range options {
+		option(&o)
+	}
+
 	input := s3.ListObjectsV2Input{
 		Bucket: aws.String(bucketName),
 		Prefix: aws.String(prefix),
 	}
+	if o.delimiter != "" {
+		input.Delimiter = aws.String(o.delimiter)
+	}
+	if o.startAfter != "" {
+		input.StartAfter = aws.String(o.startAfter)
+	}
+	if o.pageSize > 0 {
+		input.MaxKeys = aws.Int32(o.pageSize)
+	}
 
-	output, err := listObjects(ctx, client, &input)
-	if err != nil {
-		log.Error().Err(err).Msg("failed to read bucket content")
-		return nil, err
+	return func(yield func(S3File, error) bool) {
+		paginator := s3.NewListObjectsV2Paginator(client, &input)
+		for paginator.HasMorePages() {
+			output, err := paginator.NextPage(ctx)
+			if err != nil {
+				yield(S3File{}, fmt.Errorf("failed to list objects in bucket %s: %w", bucketName, err))
+				return
+			}
+
+			// S3 returns the common prefixes and the objects of a page separately, merge them so names are yielded in order
+			prefixes, contents := output.CommonPrefixes, output.Contents
+			for len(prefixes) > 0 || len(contents) > 0 {
+				var file S3File
+				if len(contents) == 0 || (len(prefixes) > 0 && aws.ToString(prefixes[0].Prefix) < aws.ToString(contents[0].Key)) {
+					file = S3File{Name: aws.ToString(prefixes[0].Prefix), IsPrefix: true}
+					prefixes = prefixes[1:]
+					// S3 returns the prefix of the keys after `startAfter` again when resuming after a prefix
+					if file.Name <= o.startAfter {
+						continue
+					}
+				} else {
+					v := contents[0]
+					file = S3File{
+						Name:         aws.ToString(v.Key),
+						CreatedAt:    aws.ToTime(v.LastModified),
+						Size:         aws.ToInt64(v.Size),
+						ETag:         aws.ToString(v.ETag),
+						StorageClass: string(v.StorageClass),
+					}
+					contents = contents[1:]
+				}
+				if !yield(file, nil) {
+					return
+				}
+			}
+		}
 	}
+}
 
-	list := make([]S3File, 0, *output.KeyCount)
-	for _, v := range output.Contents {
-		list = append(list, S3File{
-			Name:      *v.Key,
-			CreatedAt: *v.LastModified,
-		})
+// ListBucketObjects returns all objects below `prefix`, reading every page of the listing.
+func ListBucketObjects(ctx context.Context, client ListObjectsV2API, bucketName string, prefix string, options ...ListOption) ([]S3File, error) {
+	list := make([]S3File, 0)
+	for file, err := range ListObjects(ctx, client, bucketName, prefix, options...) {
+		if err != nil {
+			log.Error().Err(err).Msg("failed to read bucket content")
+			return nil, err
+		}
+		list = append(list, file)
 	}
 
 	return list, nil
@@ -112,20 +207,7 @@ func DownloadFile(ctx context.Context, api GetObjectAPI, bucketName string, obje
 }
 
 func GeneratePresignedURL(ctx context.Context, api PresignObject, bucketName string, objectKey string, mimeType string, fileName string, expiry time.Duration) (string, error) {
-	input := &s3.GetObjectInput{
-		Bucket:                     aws.String(bucketName),
-		Key:                        aws.String(objectKey),
-		ResponseContentType:        aws.String(mimeType),
-		ResponseContentDisposition: aws.String(fmt.Sprintf("attachment; filename=\"%s\"", fileName)),
-	}
-
-	// Generer en presigned URL
-	presignedURL, err := api.PresignGetObject(ctx, input, s3.WithPresignExpires(expiry))
-	if err != nil {
-		return "", fmt.Errorf("failed to generate presigned URL: %w", err)
-	}
-
-	return presignedURL.URL, nil
+	return presignGet(ctx, api, bucketName, objectKey, mimeType, "attachment", fileName, expiry)
 }
 
 // DownloadFileStream downloads file from S3 and returns the io.ReadCloser. This must be closed by the callee function!
@@ -143,7 +225,8 @@ func DownloadFileStream(ctx context.Context, api GetObjectAPI, bucketName string
 	return output.Body, nil
 }
 
-// DownloadFileLarge downloads file from S3 using download manager and returns it as a byte slice
+// DownloadFileLarge downloads file from S3 using download manager and returns it as a byte slice.
+// Use DownloadTo to download into a file instead of memory.
 func DownloadLargeFile(ctx context.Context, api GetObjectAPI, bucketName string, objectKey string) ([]byte, error) {
 	buffer := manager.NewWriteAtBuffer([]byte{})
 
@@ -0,0 +1,132 @@
+package awss3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// Lists sorted keys like S3, without setting `KeyCount` or `LastModified`.
+type ListObjectsV2Mock struct {
+	keys  []string
+	calls int
+	fail  bool
+}
+
+func (m *ListObjectsV2Mock) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	m.calls++
+	if m.fail {
+		return nil, errors.New("access denied")
+	}
+
+	start := max(aws.ToString(params.StartAfter), aws.ToString(params.ContinuationToken))
+	maxKeys := int(aws.ToInt32(params.MaxKeys))
+	if maxKeys == 0 {
+		maxKeys = 1000
+	}
+
+	output := &s3.ListObjectsV2Output{}
+	var last string
+	for _, key := range m.keys {
+		if key <= start || !strings.HasPrefix(key, aws.ToString(params.Prefix)) {
+			continue
+		}
+		if len(output.Contents)+len(output.CommonPrefixes) == maxKeys {
+			output.IsTruncated = aws.Bool(true)
+			output.NextContinuationToken = aws.String(last)
+			break
+		}
+
+		rest := strings.TrimPrefix(key, aws.ToString(params.Prefix))
+		if i := strings.Index(rest, aws.ToString(params.Delimiter)); params.Delimiter != nil && i >= 0 {
+			prefix := aws.ToString(params.Prefix) + rest[:i+1]
+			if !slices.ContainsFunc(output.CommonPrefixes, func(p types.CommonPrefix) bool { return *p.Prefix == prefix }) {
+				output.CommonPrefixes = append(output.CommonPrefixes, types.CommonPrefix{Prefix: aws.String(prefix)})
+			}
+			last = prefix + "\xff"
+			continue
+		}
+		output.Contents = append(output.Contents, types.Object{Key: aws.String(key), Size: aws.Int64(int64(len(key))), StorageClass: types.ObjectStorageClassStandard})
+		last = key
+	}
+	return output, nil
+}
+
+func keys(n int) []string {
+	list := make([]string, n)
+	for i := range list {
+		list[i] = fmt.Sprintf("data/%04d.json", i)
+	}
+	return list
+}
+
+func TestListBucketObjects_ReadsAllPages(t *testing.T) {
+	mock := &ListObjectsV2Mock{keys: keys(2500)}
+
+	files, err := ListBucketObjects(context.Background(), mock, "bucket", "data/")
+
+	assert.NoError(t, err)
+	assert.Len(t, files, 2500)
+	assert.Equal(t, 3, mock.calls)
+	assert.Equal(t, S3File{Name: "data/0000.json", Size: 14, StorageClass: "STANDARD"}, files[0])
+}
+
+func TestListObjects_StopsFetchingWhenBreaking(t *testing.T) {
+	mock := &ListObjectsV2Mock{keys: keys(50)}
+
+	var names []string
+	for file, err := range ListObjects(context.Background(), mock, "bucket", "", WithPageSize(10), WithStartAfter("data/0019.json")) {
+		assert.NoError(t, err)
+		names = append(names, file.Name)
+		if len(names) == 15 {
+			break
+		}
+	}
+
+	assert.Equal(t, "data/0020.json", names[0])
+	assert.Equal(t, "data/0034.json", names[14])
+	assert.Equal(t, 2, mock.calls)
+}
+
+func TestListObjects_GroupsCommonPrefixes(t *testing.T) {
+	mock := &ListObjectsV2Mock{keys: []string{"a/1.txt", "a/2.txt", "b/c/3.txt", "root.txt"}}
+
+	files, err := ListBucketObjects(context.Background(), mock, "bucket", "", WithDelimiter("/"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []S3File{{Name: "a/", IsPrefix: true}, {Name: "b/", IsPrefix: true}, {Name: "root.txt", Size: 8, StorageClass: "STANDARD"}}, files)
+}
+
+func TestListObjects_YieldsPrefixesInOrderAndResumesAfterPrefix(t *testing.T) {
+	mock := &ListObjectsV2Mock{keys: []string{"a.txt", "b/1.txt", "b/2.txt", "c.txt", "d/1.txt", "e.txt"}}
+
+	var names []string
+	for file, err := range ListObjects(context.Background(), mock, "bucket", "", WithDelimiter("/")) {
+		assert.NoError(t, err)
+		names = append(names, file.Name)
+		if file.Name == "b/" {
+			break
+		}
+	}
+	for file, err := range ListObjects(context.Background(), mock, "bucket", "", WithDelimiter("/"), WithStartAfter(names[len(names)-1])) {
+		assert.NoError(t, err)
+		names = append(names, file.Name)
+	}
+
+	assert.Equal(t, []string{"a.txt", "b/", "c.txt", "d/", "e.txt"}, names)
+}
+
+func TestListBucketObjects_ReturnsError(t *testing.T) {
+	files, err := ListBucketObjects(context.Background(), &ListObjectsV2Mock{fail: true}, "bucket", "")
+
+	assert.Nil(t, files)
+	assert.ErrorContains(t, err, "failed to list objects in bucket bucket: access denied")
+}
@@ -0,0 +1,347 @@
+package awss3
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+var (
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	// The object was uploaded without a CRC32C or SHA256 checksum
+	ErrNoChecksum = errors.New("object has no checksum")
+)
+
+type GetObjectAttributesAPI interface {
+	GetObjectAttributes(ctx context.Context, params *s3.GetObjectAttributesInput, optFns ...func(*s3.Options)) (*s3.GetObjectAttributesOutput, error)
+}
+
+// The operations `VerifyChecksum` uses to read the checksums of an object and of its parts.
+type ChecksumAPI interface {
+	HeadObjectAPI
+	GetObjectAttributesAPI
+}
+
+type DownloadAPI interface {
+	GetObjectAPI
+	ChecksumAPI
+}
+
+// DownloadRange downloads the bytes from `start` to `end` inclusive of an object, or to the end of it if `end` is negative.
+// The io.ReadCloser must be closed by the callee function!
+func DownloadRange(ctx context.Context, api GetObjectAPI, bucketName string, objectKey string, start int64, end int64) (io.ReadCloser, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(objectKey),
+		Range:  aws.String(byteRange(start, end)),
+	}
+
+	output, err := getObject(ctx, api, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return output.Body, nil
+}
+
+func byteRange(start int64, end int64) string {
+	if end < 0 {
+		return fmt.Sprintf("bytes=%d-", start)
+	}
+	return fmt.Sprintf("bytes=%d-%d", start, end)
+}
+
+// DownloadTo downloads an object into `w` in parallel ranged requests, e.g. into a file instead of memory like `DownloadLargeFile`.
+// Uses the part size and concurrency from `options`. With `WithChecksumVerification`, `w` must also be an io.ReaderAt, like *os.File.
+//
+// Usage:
+// ```go
+//
+//	file, _ := os.Create("/tmp/archive.zip")
+//	defer file.Close()
+//	size, err := awss3.DownloadTo(ctx, client, "my-bucket", "archive.zip", file, awss3.WithChecksumVerification())
+//
+// ```
+func DownloadTo(ctx context.Context, api DownloadAPI, bucketName string, objectKey string, w io.WriterAt, options ...ObjectOption) (int64, error) {
+	o := newObjectOptions(options)
+	reader, canRead := w.(io.ReaderAt)
+	if o.verifyChecksum && !canRead {
+		return 0, errors.New("checksum verification needs a writer that is also an io.ReaderAt")
+	}
+
+	// The downloader's ranged requests don't pin a version, so they are pinned to the version found here.
+	// S3 answers with 412 Precondition Failed if the object is replaced during the download.
+	headInput := &s3.HeadObjectInput{Bucket: aws.String(bucketName), Key: aws.String(objectKey)}
+	if o.verifyChecksum {
+		headInput.ChecksumMode = types.ChecksumModeEnabled
+	}
+	head, err := api.HeadObject(ctx, headInput)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s/%s: %w", bucketName, objectKey, err)
+	}
+
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(objectKey),
+	}
+	if aws.ToString(head.ETag) != "" {
+		input.IfMatch = head.ETag
+	}
+	if aws.ToString(head.VersionId) != "" {
+		input.VersionId = head.VersionId
+	}
+	downloader := manager.NewDownloader(api, func(d *manager.Downloader) {
+		d.PartSize = o.partSize
+		d.Concurrency = o.concurrency
+	})
+	size, err := downloader.Download(ctx, w, input)
+	if err != nil {
+		return size, err
+	}
+
+	if o.verifyChecksum {
+		return size, verifyChecksum(ctx, api, bucketName, objectKey, head, io.NewSectionReader(reader, 0, size))
+	}
+	return size, nil
+}
+
+// VerifyChecksum compares the content of `r` with the CRC32C or SHA256 checksum S3 stores for an object.
+// Objects uploaded in parts have a checksum of the part checksums, so their parts are verified one by one against the part checksums from `GetObjectAttributes`.
+// Returns `ErrChecksumMismatch` if they differ, and `ErrNoChecksum` if the object has no checksum.
+func VerifyChecksum(ctx context.Context, api ChecksumAPI, bucketName string, objectKey string, r io.Reader) error {
+	output, err := api.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(bucketName),
+		Key:          aws.String(objectKey),
+		ChecksumMode: types.ChecksumModeEnabled,
+	})
+	if err != nil {
+		return err
+	}
+	return verifyChecksum(ctx, api, bucketName, objectKey, output, r)
+}
+
+// Verifies `r` against the checksums of the version of the object described by `head`.
+func verifyChecksum(ctx context.Context, api GetObjectAttributesAPI, bucketName string, objectKey string, head *s3.HeadObjectOutput, r io.Reader) error {
+	expected, h := checksum(head.ChecksumCRC32C, head.ChecksumSHA256)
+	if h == nil {
+		return fmt.Errorf("%w: %s/%s", ErrNoChecksum, bucketName, objectKey)
+	}
+	// Checksums of multipart uploads are checksums of the part checksums, ending with the number of parts, e.g. "...-3"
+	if strings.Contains(expected, "-") {
+		return verifyPartChecksums(ctx, api, bucketName, objectKey, head, r)
+	}
+
+	if _, err := io.Copy(h, r); err != nil {
+		return err
+	}
+	if actual := base64.StdEncoding.EncodeToString(h.Sum(nil)); actual != expected {
+		return fmt.Errorf("%w: %s/%s has checksum %s, downloaded content has %s", ErrChecksumMismatch, bucketName, objectKey, expected, actual)
+	}
+	return nil
+}
+
+func verifyPartChecksums(ctx context.Context, api GetObjectAttributesAPI, bucketName string, objectKey string, head *s3.HeadObjectOutput, r io.Reader) error {
+	input := &s3.GetObjectAttributesInput{
+		Bucket:           aws.String(bucketName),
+		Key:              aws.String(objectKey),
+		ObjectAttributes: []types.ObjectAttributes{types.ObjectAttributesObjectParts, types.ObjectAttributesEtag},
+	}
+	if aws.ToString(head.VersionId) != "" {
+		input.VersionId = head.VersionId
+	}
+	for {
+		output, err := api.GetObjectAttributes(ctx, input)
+		if err != nil {
+			return err
+		}
+		// Unversioned objects can only be pinned by comparing ETags, which GetObjectAttributes returns without quotes
+		if etag := strings.Trim(aws.ToString(head.ETag), `"`); etag != "" && aws.ToString(output.ETag) != "" && strings.Trim(*output.ETag, `"`) != etag {
+			return fmt.Errorf("%s/%s was replaced while verifying its checksum", bucketName, objectKey)
+		}
+		if output.ObjectParts == nil {
+			return fmt.Errorf("%w: %s/%s has no part checksums", ErrNoChecksum, bucketName, objectKey)
+		}
+
+		for _, part := range output.ObjectParts.Parts {
+			expected, h := checksum(part.ChecksumCRC32C, part.ChecksumSHA256)
+			if h == nil {
+				return fmt.Errorf("%w: part %d of %s/%s", ErrNoChecksum, aws.ToInt32(part.PartNumber), bucketName, objectKey)
+			}
+			if _, err := io.CopyN(h, r, aws.ToInt64(part.Size)); err != nil && err != io.EOF {
+				return err
+			}
+			if actual := base64.StdEncoding.EncodeToString(h.Sum(nil)); actual != expected {
+				return fmt.Errorf("%w: part %d of %s/%s has checksum %s, downloaded content has %s", ErrChecksumMismatch, aws.ToInt32(part.PartNumber), bucketName, objectKey, expected, actual)
+			}
+		}
+
+		if !aws.ToBool(output.ObjectParts.IsTruncated) {
+			break
+		}
+		input.PartNumberMarker = output.ObjectParts.NextPartNumberMarker
+	}
+
+	if n, err := io.Copy(io.Discard, r); err != nil {
+		return err
+	} else if n > 0 {
+		return fmt.Errorf("%w: downloaded content is %d bytes longer than the parts of %s/%s", ErrChecksumMismatch, n, bucketName, objectKey)
+	}
+	return nil
+}
+
+// Returns the CRC32C checksum if present, otherwise the SHA256 checksum, with the hash computing it. The hash is nil if there is neither.
+func checksum(crc32c *string, sha256sum *string) (string, hash.Hash) {
+	switch {
+	case aws.ToString(crc32c) != "":
+		return *crc32c, crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	case aws.ToString(sha256sum) != "":
+		return *sha256sum, sha256.New()
+	}
+	return "", nil
+}
+
+// A seekable reader of an S3 object, downloading only what is read with range requests.
+// Reads after a seek or with `ReadAt` start a new request, so it suits formats like zip archives that read parts of a file.
+// All requests read the version of the object found by `OpenObject`, and fail if it has been replaced since.
+type ObjectReader struct {
+	ctx       context.Context
+	api       GetObjectAPI
+	bucket    string
+	key       string
+	etag      string
+	versionId string
+	size      int64
+	offset    int64
+	body      io.ReadCloser
+}
+
+var _ io.ReadSeekCloser = (*ObjectReader)(nil)
+var _ io.ReaderAt = (*ObjectReader)(nil)
+
+// OpenObject returns a reader of an object without downloading it. Returns `ErrObjectNotFound` if the object doesn't exist.
+// The reader must be closed by the callee function!
+//
+// Usage:
+// ```go
+//
+//	object, err := awss3.OpenObject(ctx, client, "my-bucket", "archive.zip")
+//	defer object.Close()
+//	archive, err := zip.NewReader(object, object.Size())
+//
+// ```
+func OpenObject(ctx context.Context, api DownloadAPI, bucketName string, objectKey string) (*ObjectReader, error) {
+	info, err := HeadObject(ctx, api, bucketName, objectKey)
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectReader{ctx: ctx, api: api, bucket: bucketName, key: objectKey, etag: info.ETag, versionId: info.VersionId, size: info.Size}, nil
+}
+
+// Downloads a range of the opened version of the object. S3 answers with 412 Precondition Failed if the ETag no longer matches.
+func (r *ObjectReader) downloadRange(start int64, end int64) (io.ReadCloser, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(r.key),
+		Range:  aws.String(byteRange(start, end)),
+	}
+	if r.etag != "" {
+		input.IfMatch = aws.String(r.etag)
+	}
+	if r.versionId != "" {
+		input.VersionId = aws.String(r.versionId)
+	}
+
+	output, err := getObject(r.ctx, r.api, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s/%s: %w", r.bucket, r.key, err)
+	}
+	return output.Body, nil
+}
+
+func (r *ObjectReader) Size() int64 {
+	return r.size
+}
+
+func (r *ObjectReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		body, err := r.downloadRange(r.offset, -1)
+		if err != nil {
+			return 0, err
+		}
+		r.body = body
+	}
+
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	if err == io.EOF && r.offset < r.size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (r *ObjectReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	if offset != r.offset && r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+func (r *ObjectReader) ReadAt(p []byte, offset int64) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if offset >= r.size {
+		return 0, io.EOF
+	}
+	end := min(offset+int64(len(p)), r.size) - 1
+
+	body, err := r.downloadRange(offset, end)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+
+	n, err := io.ReadFull(body, p[:end-offset+1])
+	if err == nil && n < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (r *ObjectReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
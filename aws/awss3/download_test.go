@@ -0,0 +1,312 @@
+package awss3
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// Serves ranged GETs of a single object, with its checksums from HeadObject.
+type RangeMock struct {
+	mu             sync.Mutex
+	data           []byte
+	checksumCRC32C *string
+	checksumSHA256 *string
+	etag           string
+	versionId      string
+	parts          []types.ObjectPart
+	ranges         []string
+	versions       []string
+	ifMatches      []string
+	// Replaces the object with a new version after this many GETs if set
+	replaceAfter int
+}
+
+func (m *RangeMock) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	m.mu.Lock()
+	m.ranges = append(m.ranges, aws.ToString(params.Range))
+	m.versions = append(m.versions, aws.ToString(params.VersionId))
+	m.ifMatches = append(m.ifMatches, aws.ToString(params.IfMatch))
+	if m.replaceAfter > 0 && len(m.ranges) > m.replaceAfter {
+		m.etag, m.versionId = `"new-etag"`, "new"
+	}
+	etag := m.etag
+	m.mu.Unlock()
+	if params.IfMatch != nil && *params.IfMatch != etag {
+		return nil, errors.New("PreconditionFailed")
+	}
+
+	size := int64(len(m.data))
+	if params.Range == nil {
+		return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(m.data)), ContentLength: aws.Int64(size)}, nil
+	}
+
+	start, end, _ := strings.Cut(strings.TrimPrefix(*params.Range, "bytes="), "-")
+	first, _ := strconv.ParseInt(start, 10, 64)
+	last := size - 1
+	if end != "" {
+		last, _ = strconv.ParseInt(end, 10, 64)
+		last = min(last, size-1)
+	}
+	return &s3.GetObjectOutput{
+		Body:          io.NopCloser(bytes.NewReader(m.data[first : last+1])),
+		ContentLength: aws.Int64(last - first + 1),
+		ContentRange:  aws.String(fmt.Sprintf("bytes %d-%d/%d", first, last, size)),
+	}, nil
+}
+
+func (m *RangeMock) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	output := &s3.HeadObjectOutput{ContentLength: aws.Int64(int64(len(m.data))), ETag: aws.String(m.etag), VersionId: aws.String(m.versionId)}
+	if params.ChecksumMode == types.ChecksumModeEnabled {
+		output.ChecksumCRC32C, output.ChecksumSHA256 = m.checksumCRC32C, m.checksumSHA256
+	}
+	return output, nil
+}
+
+// Returns two parts per page to exercise pagination.
+func (m *RangeMock) GetObjectAttributes(ctx context.Context, params *s3.GetObjectAttributesInput, optFns ...func(*s3.Options)) (*s3.GetObjectAttributesOutput, error) {
+	m.mu.Lock()
+	m.versions = append(m.versions, aws.ToString(params.VersionId))
+	etag := strings.Trim(m.etag, `"`)
+	m.mu.Unlock()
+	if m.parts == nil {
+		return &s3.GetObjectAttributesOutput{ETag: aws.String(etag)}, nil
+	}
+	start, _ := strconv.Atoi(aws.ToString(params.PartNumberMarker))
+	end := min(start+2, len(m.parts))
+	output := &s3.GetObjectAttributesOutput{ETag: aws.String(etag), ObjectParts: &types.GetObjectAttributesParts{Parts: m.parts[start:end], TotalPartsCount: aws.Int32(int32(len(m.parts)))}}
+	if end < len(m.parts) {
+		output.ObjectParts.IsTruncated = aws.Bool(true)
+		output.ObjectParts.NextPartNumberMarker = aws.String(strconv.Itoa(end))
+	}
+	return output, nil
+}
+
+// Splits `data` into parts of `size` bytes with their checksums computed by `checksum`.
+func parts(data []byte, size int, checksum func([]byte) *string) []types.ObjectPart {
+	var list []types.ObjectPart
+	for i := 0; i < len(data); i += size {
+		part := data[i:min(i+size, len(data))]
+		list = append(list, types.ObjectPart{PartNumber: aws.Int32(int32(len(list) + 1)), Size: aws.Int64(int64(len(part))), ChecksumCRC32C: checksum(part)})
+	}
+	return list
+}
+
+func crc32c(data []byte) *string {
+	sum := crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli))
+	return aws.String(base64.StdEncoding.EncodeToString([]byte{byte(sum >> 24), byte(sum >> 16), byte(sum >> 8), byte(sum)}))
+}
+
+func sha256sum(data []byte) *string {
+	sum := sha256.Sum256(data)
+	return aws.String(base64.StdEncoding.EncodeToString(sum[:]))
+}
+
+func testData(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	return data
+}
+
+func TestDownloadRange(t *testing.T) {
+	mock := &RangeMock{data: []byte("0123456789")}
+
+	body, err := DownloadRange(context.Background(), mock, "bucket", "file.txt", 2, 5)
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(body)
+	assert.Equal(t, "2345", string(data))
+
+	body, err = DownloadRange(context.Background(), mock, "bucket", "file.txt", 7, -1)
+	assert.NoError(t, err)
+	data, _ = io.ReadAll(body)
+	assert.Equal(t, "789", string(data))
+	assert.Equal(t, []string{"bytes=2-5", "bytes=7-"}, mock.ranges)
+}
+
+func TestDownloadTo_FileInPartsWithChecksum(t *testing.T) {
+	data := testData(2500)
+	mock := &RangeMock{data: data, checksumCRC32C: crc32c(data)}
+	file, err := os.Create(filepath.Join(t.TempDir(), "download"))
+	assert.NoError(t, err)
+	defer file.Close()
+
+	size, err := DownloadTo(context.Background(), mock, "bucket", "file.bin", file, WithPartSize(1000), WithConcurrency(2), WithChecksumVerification())
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2500), size)
+	assert.Len(t, mock.ranges, 3)
+	written, _ := os.ReadFile(file.Name())
+	assert.Equal(t, data, written)
+}
+
+func TestDownloadTo_VerifiesChecksums(t *testing.T) {
+	data := testData(100)
+	corrupted := parts(data, 30, crc32c)
+	corrupted[2].ChecksumCRC32C = crc32c([]byte("other"))
+	tests := map[string]struct {
+		crc32c, sha256 *string
+		parts          []types.ObjectPart
+		err            error
+	}{
+		"CRC32C":                     {crc32c: crc32c(data)},
+		"SHA256":                     {sha256: sha256sum(data)},
+		"CRC32C mismatch":            {crc32c: crc32c([]byte("other")), sha256: sha256sum(data), err: ErrChecksumMismatch},
+		"SHA256 mismatch":            {sha256: sha256sum([]byte("other")), err: ErrChecksumMismatch},
+		"No checksum":                {err: ErrNoChecksum},
+		"Multipart upload":           {crc32c: aws.String("AAAAAA==-4"), parts: parts(data, 30, crc32c)},
+		"Multipart part mismatch":    {crc32c: aws.String("AAAAAA==-4"), parts: corrupted, err: ErrChecksumMismatch},
+		"Multipart shorter parts":    {crc32c: aws.String("AAAAAA==-3"), parts: parts(data[:90], 30, crc32c), err: ErrChecksumMismatch},
+		"Multipart without checksum": {crc32c: aws.String("AAAAAA==-4"), parts: parts(data, 30, func([]byte) *string { return nil }), err: ErrNoChecksum},
+		"Not verified":               {},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mock := &RangeMock{data: data, checksumCRC32C: test.crc32c, checksumSHA256: test.sha256, parts: test.parts}
+			options := []ObjectOption{WithChecksumVerification()}
+			if name == "Not verified" {
+				options = nil
+			}
+
+			_, err := DownloadTo(context.Background(), mock, "bucket", "file.bin", &writerAtBuffer{}, options...)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	_, err := DownloadTo(context.Background(), &RangeMock{data: data}, "bucket", "file.bin", writerAtOnly{&writerAtBuffer{}}, WithChecksumVerification())
+	assert.ErrorContains(t, err, "needs a writer that is also an io.ReaderAt")
+}
+
+func TestDownloadTo_PinsVersionFoundFirst(t *testing.T) {
+	data := testData(2500)
+	mock := &RangeMock{data: data, checksumCRC32C: aws.String("AAAAAA==-3"), parts: parts(data, 1000, crc32c), etag: `"v1-etag"`, versionId: "v1"}
+
+	_, err := DownloadTo(context.Background(), mock, "bucket", "file.bin", &writerAtBuffer{}, WithPartSize(1000), WithConcurrency(1), WithChecksumVerification())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"v1", "v1", "v1", "v1", "v1"}, mock.versions)
+	assert.Equal(t, []string{`"v1-etag"`, `"v1-etag"`, `"v1-etag"`}, mock.ifMatches)
+
+	replaced := &RangeMock{data: data, etag: `"v1-etag"`, replaceAfter: 1}
+	_, err = DownloadTo(context.Background(), replaced, "bucket", "file.bin", &writerAtBuffer{}, WithPartSize(1000), WithConcurrency(1))
+	assert.ErrorContains(t, err, "PreconditionFailed")
+
+	mock.etag = `"v2-etag"`
+	err = verifyPartChecksums(context.Background(), mock, "bucket", "file.bin", &s3.HeadObjectOutput{ETag: aws.String(`"v1-etag"`)}, bytes.NewReader(data))
+	assert.ErrorContains(t, err, "bucket/file.bin was replaced while verifying its checksum")
+}
+
+type writerAtBuffer struct {
+	data []byte
+}
+
+func (b *writerAtBuffer) WriteAt(p []byte, offset int64) (int, error) {
+	if end := int(offset) + len(p); end > len(b.data) {
+		b.data = append(b.data, make([]byte, end-len(b.data))...)
+	}
+	return copy(b.data[offset:], p), nil
+}
+
+func (b *writerAtBuffer) ReadAt(p []byte, offset int64) (int, error) {
+	return bytes.NewReader(b.data).ReadAt(p, offset)
+}
+
+type writerAtOnly struct {
+	io.WriterAt
+}
+
+func TestObjectReader_SeeksAndReadsLazily(t *testing.T) {
+	mock := &RangeMock{data: []byte("0123456789")}
+
+	object, err := OpenObject(context.Background(), mock, "bucket", "file.txt")
+	assert.NoError(t, err)
+	defer object.Close()
+	assert.Equal(t, int64(10), object.Size())
+	assert.Empty(t, mock.ranges)
+
+	buffer := make([]byte, 3)
+	_, err = io.ReadFull(object, buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, "012", string(buffer))
+	_, err = io.ReadFull(object, buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, "345", string(buffer))
+
+	position, _ := object.Seek(-2, io.SeekEnd)
+	assert.Equal(t, int64(8), position)
+	rest, err := io.ReadAll(object)
+	assert.NoError(t, err)
+	assert.Equal(t, "89", string(rest))
+
+	n, err := object.ReadAt(buffer, 8)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, []string{"bytes=0-", "bytes=8-", "bytes=8-9"}, mock.ranges)
+}
+
+func TestObjectReader_ReadsOpenedVersion(t *testing.T) {
+	mock := &RangeMock{data: []byte("0123456789"), etag: `"v1-etag"`, versionId: "v1"}
+
+	object, err := OpenObject(context.Background(), mock, "bucket", "file.txt")
+	assert.NoError(t, err)
+	defer object.Close()
+	buffer := make([]byte, 3)
+	_, err = object.ReadAt(buffer, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"v1"}, mock.versions)
+
+	mock.etag = `"v2-etag"`
+	_, err = object.ReadAt(buffer, 3)
+	assert.ErrorContains(t, err, "failed to read bucket/file.txt: PreconditionFailed")
+	_, err = object.Read(buffer)
+	assert.ErrorContains(t, err, "PreconditionFailed")
+}
+
+func TestObjectReader_ReadsZipArchive(t *testing.T) {
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	for i := range 20 {
+		file, _ := writer.Create(fmt.Sprintf("file-%d.txt", i))
+		_, _ = file.Write(testData(1000))
+	}
+	_ = writer.Close()
+	mock := &RangeMock{data: archive.Bytes()}
+
+	object, err := OpenObject(context.Background(), mock, "bucket", "archive.zip")
+	assert.NoError(t, err)
+	reader, err := zip.NewReader(object, object.Size())
+	assert.NoError(t, err)
+	file, err := reader.Open("file-7.txt")
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(file)
+
+	assert.Equal(t, testData(1000), data)
+	for _, r := range mock.ranges {
+		assert.NotEqual(t, "bytes=0-", r)
+	}
+}
@@ -0,0 +1,358 @@
+package awss3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// The maximum number of keys in a single `DeleteObjects` request.
+const deleteBatchSize = 1000
+
+var ErrObjectNotFound = errors.New("object not found")
+
+// The operations used by the transfer manager for single and multipart uploads.
+type UploadAPI interface {
+	manager.UploadAPIClient
+}
+
+type CopyObjectAPI interface {
+	CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error)
+}
+
+// The operations used by `CopyObject`, which reads the headers of the source when replacing some of them.
+type CopyAPI interface {
+	CopyObjectAPI
+	HeadObjectAPI
+}
+
+type DeleteObjectsAPI interface {
+	DeleteObjects(ctx context.Context, params *s3.DeleteObjectsInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error)
+}
+
+type HeadObjectAPI interface {
+	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+}
+
+type GetObjectTaggingAPI interface {
+	GetObjectTagging(ctx context.Context, params *s3.GetObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.GetObjectTaggingOutput, error)
+}
+
+type PutObjectTaggingAPI interface {
+	PutObjectTagging(ctx context.Context, params *s3.PutObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.PutObjectTaggingOutput, error)
+}
+
+type objectOptions struct {
+	partSize       int64
+	concurrency    int
+	contentType    string
+	metadata       map[string]string
+	tags           map[string]string
+	kms            bool
+	kmsKeyId       string
+	checksum       types.ChecksumAlgorithm
+	verifyChecksum bool
+}
+
+type ObjectOption func(*objectOptions)
+
+// Size in bytes of the parts of multipart uploads and ranged downloads, at least 5 MiB for uploads. Defaults to 10 MiB.
+func WithPartSize(bytes int64) ObjectOption {
+	return func(o *objectOptions) {
+		o.partSize = bytes
+	}
+}
+
+// Number of parts uploaded or downloaded in parallel. Defaults to 5.
+func WithConcurrency(concurrency int) ObjectOption {
+	return func(o *objectOptions) {
+		o.concurrency = concurrency
+	}
+}
+
+func WithContentType(contentType string) ObjectOption {
+	return func(o *objectOptions) {
+		o.contentType = contentType
+	}
+}
+
+// User-defined metadata, returned as `x-amz-meta-*` headers. Replaces the user-defined metadata of the source when copying.
+func WithMetadata(metadata map[string]string) ObjectOption {
+	return func(o *objectOptions) {
+		o.metadata = metadata
+	}
+}
+
+// Replaces the tags of the source when copying.
+func WithTags(tags map[string]string) ObjectOption {
+	return func(o *objectOptions) {
+		o.tags = tags
+	}
+}
+
+// Encrypts the object with SSE-KMS using `keyId`, or the AWS managed key `aws/s3` if empty.
+func WithKMSEncryption(keyId string) ObjectOption {
+	return func(o *objectOptions) {
+		o.kms = true
+		o.kmsKeyId = keyId
+	}
+}
+
+// Has S3 store a checksum of uploads, e.g. `types.ChecksumAlgorithmCrc32c`, verified by S3 on upload and by `VerifyChecksum` after downloads.
+// Uploads in multiple parts get a checksum of each part, which `VerifyChecksum` verifies part by part.
+func WithChecksumAlgorithm(algorithm types.ChecksumAlgorithm) ObjectOption {
+	return func(o *objectOptions) {
+		o.checksum = algorithm
+	}
+}
+
+// Verifies the CRC32C or SHA256 checksum of downloads on completion, see `VerifyChecksum`.
+func WithChecksumVerification() ObjectOption {
+	return func(o *objectOptions) {
+		o.verifyChecksum = true
+	}
+}
+
+func newObjectOptions(options []ObjectOption) objectOptions {
+	o := objectOptions{partSize: 10 * 1024 * 1024, concurrency: manager.DefaultUploadConcurrency}
+	for _, option := range options {
+		option(&o)
+	}
+	return o
+}
+
+func (o objectOptions) encryption() (types.ServerSideEncryption, *string) {
+	if !o.kms {
+		return "", nil
+	}
+	var keyId *string
+	if o.kmsKeyId != "" {
+		keyId = aws.String(o.kmsKeyId)
+	}
+	return types.ServerSideEncryptionAwsKms, keyId
+}
+
+func (o objectOptions) tagging() *string {
+	if len(o.tags) == 0 {
+		return nil
+	}
+	values := url.Values{}
+	for key, value := range o.tags {
+		values.Set(key, value)
+	}
+	return aws.String(values.Encode())
+}
+
+type UploadInfo struct {
+	Location  string
+	ETag      string
+	VersionId string
+}
+
+// UploadStream uploads `body` to S3 with the transfer manager, in parallel parts if it's larger than the part size.
+//
+// Usage:
+// ```go
+//
+//	info, err := awss3.UploadStream(ctx, client, "my-bucket", "reports/2024.csv", reader,
+//		awss3.WithContentType("text/csv"), awss3.WithKMSEncryption(""))
+//
+// ```
+func UploadStream(ctx context.Context, api UploadAPI, bucketName string, objectKey string, body io.Reader, options ...ObjectOption) (*UploadInfo, error) {
+	o := newObjectOptions(options)
+	encryption, keyId := o.encryption()
+
+	input := &s3.PutObjectInput{
+		Bucket:               aws.String(bucketName),
+		Key:                  aws.String(objectKey),
+		Body:                 body,
+		Metadata:             o.metadata,
+		Tagging:              o.tagging(),
+		ServerSideEncryption: encryption,
+		SSEKMSKeyId:          keyId,
+		ChecksumAlgorithm:    o.checksum,
+	}
+	if o.contentType != "" {
+		input.ContentType = aws.String(o.contentType)
+	}
+	uploader := manager.NewUploader(api, func(u *manager.Uploader) {
+		u.PartSize = o.partSize
+		u.Concurrency = o.concurrency
+	})
+	output, err := uploader.Upload(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload %s to bucket %s: %w", objectKey, bucketName, err)
+	}
+
+	return &UploadInfo{
+		Location:  output.Location,
+		ETag:      aws.ToString(output.ETag),
+		VersionId: aws.ToString(output.VersionID),
+	}, nil
+}
+
+// UploadFile uploads the file at `path` to S3. The content type is guessed from the file extension unless given.
+func UploadFile(ctx context.Context, api UploadAPI, bucketName string, objectKey string, path string, options ...ObjectOption) (*UploadInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if contentType := mime.TypeByExtension(filepath.Ext(path)); contentType != "" {
+		options = append([]ObjectOption{WithContentType(contentType)}, options...)
+	}
+	return UploadStream(ctx, api, bucketName, objectKey, file, options...)
+}
+
+// CopyObject copies an object within or between buckets, keeping its metadata and tags unless new ones are given.
+// With `WithContentType` or `WithMetadata`, the other headers and metadata of the source are kept.
+func CopyObject(ctx context.Context, api CopyAPI, sourceBucket string, sourceKey string, bucketName string, objectKey string, options ...ObjectOption) error {
+	o := newObjectOptions(options)
+	encryption, keyId := o.encryption()
+
+	input := &s3.CopyObjectInput{
+		Bucket:               aws.String(bucketName),
+		Key:                  aws.String(objectKey),
+		CopySource:           aws.String((&url.URL{Path: sourceBucket + "/" + sourceKey}).EscapedPath()),
+		ServerSideEncryption: encryption,
+		SSEKMSKeyId:          keyId,
+	}
+	if o.metadata != nil || o.contentType != "" {
+		// S3 can only replace all headers and metadata at once, so the ones that aren't given are copied from the source
+		source, err := api.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(sourceBucket), Key: aws.String(sourceKey)})
+		if err != nil {
+			return fmt.Errorf("failed to read metadata of %s/%s: %w", sourceBucket, sourceKey, err)
+		}
+
+		input.MetadataDirective = types.MetadataDirectiveReplace
+		input.Metadata = source.Metadata
+		input.ContentType = source.ContentType
+		input.CacheControl = source.CacheControl
+		input.ContentDisposition = source.ContentDisposition
+		input.ContentEncoding = source.ContentEncoding
+		input.ContentLanguage = source.ContentLanguage
+		if o.metadata != nil {
+			input.Metadata = o.metadata
+		}
+		if o.contentType != "" {
+			input.ContentType = aws.String(o.contentType)
+		}
+	}
+	if o.tags != nil {
+		input.TaggingDirective = types.TaggingDirectiveReplace
+		input.Tagging = o.tagging()
+	}
+
+	if _, err := api.CopyObject(ctx, input); err != nil {
+		return fmt.Errorf("failed to copy %s/%s to %s/%s: %w", sourceBucket, sourceKey, bucketName, objectKey, err)
+	}
+	return nil
+}
+
+// DeleteObjects deletes `objectKeys` in batches of 1000, returning the keys that couldn't be deleted as a joined error.
+func DeleteObjects(ctx context.Context, api DeleteObjectsAPI, bucketName string, objectKeys []string) error {
+	var errs []error
+	for keys := range slices.Chunk(objectKeys, deleteBatchSize) {
+		objects := make([]types.ObjectIdentifier, len(keys))
+		for i, key := range keys {
+			objects[i] = types.ObjectIdentifier{Key: aws.String(key)}
+		}
+
+		output, err := api.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(bucketName),
+			Delete: &types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete objects in bucket %s: %w", bucketName, err))
+			continue
+		}
+		for _, e := range output.Errors {
+			errs = append(errs, fmt.Errorf("failed to delete %s: %s: %s", aws.ToString(e.Key), aws.ToString(e.Code), aws.ToString(e.Message)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type ObjectInfo struct {
+	Key                  string
+	Size                 int64
+	ContentType          string
+	ETag                 string
+	LastModified         time.Time
+	VersionId            string
+	Metadata             map[string]string
+	ServerSideEncryption string
+	KMSKeyId             string
+}
+
+// HeadObject returns the size, content type, metadata and ETag of an object without downloading it.
+// Returns `ErrObjectNotFound` if the object doesn't exist.
+func HeadObject(ctx context.Context, api HeadObjectAPI, bucketName string, objectKey string) (*ObjectInfo, error) {
+	output, err := api.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(objectKey),
+	})
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return nil, fmt.Errorf("%w: %s/%s", ErrObjectNotFound, bucketName, objectKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &ObjectInfo{
+		Key:                  objectKey,
+		Size:                 aws.ToInt64(output.ContentLength),
+		ContentType:          aws.ToString(output.ContentType),
+		ETag:                 aws.ToString(output.ETag),
+		LastModified:         aws.ToTime(output.LastModified),
+		VersionId:            aws.ToString(output.VersionId),
+		Metadata:             output.Metadata,
+		ServerSideEncryption: string(output.ServerSideEncryption),
+		KMSKeyId:             aws.ToString(output.SSEKMSKeyId),
+	}, nil
+}
+
+func GetObjectTags(ctx context.Context, api GetObjectTaggingAPI, bucketName string, objectKey string) (map[string]string, error) {
+	output, err := api.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(objectKey),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string]string, len(output.TagSet))
+	for _, tag := range output.TagSet {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags, nil
+}
+
+// PutObjectTags replaces all tags of an object.
+func PutObjectTags(ctx context.Context, api PutObjectTaggingAPI, bucketName string, objectKey string, tags map[string]string) error {
+	tagSet := make([]types.Tag, 0, len(tags))
+	for key, value := range tags {
+		tagSet = append(tagSet, types.Tag{Key: aws.String(key), Value: aws.String(value)})
+	}
+
+	_, err := api.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+		Bucket:  aws.String(bucketName),
+		Key:     aws.String(objectKey),
+		Tagging: &types.Tagging{TagSet: tagSet},
+	})
+	return err
+}
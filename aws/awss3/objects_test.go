@@ -0,0 +1,195 @@
+package awss3
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// Records the requests of the S3 operations used by the transfer manager and the object functions.
+type S3Mock struct {
+	mu        sync.Mutex
+	puts      []*s3.PutObjectInput
+	parts     map[int32][]byte
+	completed *s3.CompleteMultipartUploadInput
+	copies    []*s3.CopyObjectInput
+	deletes   []*s3.DeleteObjectsInput
+	failKeys  map[string]bool
+	head      *s3.HeadObjectOutput
+	tags      []types.Tag
+}
+
+func (m *S3Mock) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.puts = append(m.puts, params)
+	return &s3.PutObjectOutput{ETag: aws.String(`"etag"`), VersionId: aws.String("v1")}, nil
+}
+
+func (m *S3Mock) CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.parts = map[int32][]byte{}
+	m.puts = append(m.puts, &s3.PutObjectInput{Bucket: params.Bucket, Key: params.Key, ServerSideEncryption: params.ServerSideEncryption, SSEKMSKeyId: params.SSEKMSKeyId})
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String("upload")}, nil
+}
+
+func (m *S3Mock) UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	data, _ := io.ReadAll(params.Body)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.parts[*params.PartNumber] = data
+	return &s3.UploadPartOutput{ETag: aws.String(fmt.Sprintf(`"part-%d"`, *params.PartNumber))}, nil
+}
+
+func (m *S3Mock) CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	m.completed = params
+	return &s3.CompleteMultipartUploadOutput{ETag: aws.String(`"etag-multipart"`)}, nil
+}
+
+func (m *S3Mock) AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func (m *S3Mock) CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error) {
+	m.copies = append(m.copies, params)
+	return &s3.CopyObjectOutput{}, nil
+}
+
+func (m *S3Mock) DeleteObjects(ctx context.Context, params *s3.DeleteObjectsInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error) {
+	m.deletes = append(m.deletes, params)
+	output := &s3.DeleteObjectsOutput{}
+	for _, object := range params.Delete.Objects {
+		if m.failKeys[*object.Key] {
+			output.Errors = append(output.Errors, types.Error{Key: object.Key, Code: aws.String("AccessDenied"), Message: aws.String("Access Denied")})
+		}
+	}
+	return output, nil
+}
+
+func (m *S3Mock) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	if m.head == nil {
+		return nil, &types.NotFound{}
+	}
+	return m.head, nil
+}
+
+func (m *S3Mock) GetObjectTagging(ctx context.Context, params *s3.GetObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.GetObjectTaggingOutput, error) {
+	return &s3.GetObjectTaggingOutput{TagSet: m.tags}, nil
+}
+
+func (m *S3Mock) PutObjectTagging(ctx context.Context, params *s3.PutObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.PutObjectTaggingOutput, error) {
+	m.tags = params.Tagging.TagSet
+	return &s3.PutObjectTaggingOutput{}, nil
+}
+
+func TestUploadStream_SinglePartWithOptions(t *testing.T) {
+	mock := &S3Mock{}
+
+	info, err := UploadStream(context.Background(), mock, "bucket", "reports/2024.csv", strings.NewReader("a,b\n1,2\n"),
+		WithContentType("text/csv"), WithMetadata(map[string]string{"owner": "team"}), WithTags(map[string]string{"env": "test", "cost center": "42"}), WithKMSEncryption("key-id"),
+		WithChecksumAlgorithm(types.ChecksumAlgorithmCrc32c))
+
+	assert.NoError(t, err)
+	assert.Equal(t, `"etag"`, info.ETag)
+	assert.Equal(t, "v1", info.VersionId)
+	assert.Len(t, mock.puts, 1)
+	put := mock.puts[0]
+	assert.Equal(t, "text/csv", *put.ContentType)
+	assert.Equal(t, map[string]string{"owner": "team"}, put.Metadata)
+	assert.Equal(t, "cost+center=42&env=test", *put.Tagging)
+	assert.Equal(t, types.ServerSideEncryptionAwsKms, put.ServerSideEncryption)
+	assert.Equal(t, "key-id", *put.SSEKMSKeyId)
+	assert.Equal(t, types.ChecksumAlgorithmCrc32c, put.ChecksumAlgorithm)
+}
+
+func TestUploadStream_MultipartWithPartSize(t *testing.T) {
+	mock := &S3Mock{}
+	data := bytes.Repeat([]byte("x"), 12*1024*1024)
+
+	info, err := UploadStream(context.Background(), mock, "bucket", "large.bin", bytes.NewReader(data), WithPartSize(5*1024*1024), WithConcurrency(2), WithKMSEncryption(""))
+
+	assert.NoError(t, err)
+	assert.Equal(t, `"etag-multipart"`, info.ETag)
+	assert.Len(t, mock.parts, 3)
+	assert.Len(t, mock.completed.MultipartUpload.Parts, 3)
+	assert.Equal(t, types.ServerSideEncryptionAwsKms, mock.puts[0].ServerSideEncryption)
+	assert.Nil(t, mock.puts[0].SSEKMSKeyId)
+}
+
+func TestCopyObject_EncodesSourceAndReplacesMetadata(t *testing.T) {
+	mock := &S3Mock{head: &s3.HeadObjectOutput{ContentType: aws.String("text/plain"), Metadata: map[string]string{"owner": "team"}}}
+
+	assert.NoError(t, CopyObject(context.Background(), mock, "source", "dir/file name+1.txt", "target", "copy.txt"))
+	assert.NoError(t, CopyObject(context.Background(), mock, "source", "a.txt", "target", "b.txt", WithMetadata(map[string]string{"k": "v"}), WithTags(map[string]string{"t": "1"})))
+
+	assert.Equal(t, "source/dir/file%20name+1.txt", *mock.copies[0].CopySource)
+	assert.Empty(t, mock.copies[0].MetadataDirective)
+	assert.Empty(t, mock.copies[0].TaggingDirective)
+	assert.Equal(t, types.MetadataDirectiveReplace, mock.copies[1].MetadataDirective)
+	assert.Equal(t, map[string]string{"k": "v"}, mock.copies[1].Metadata)
+	assert.Equal(t, "text/plain", *mock.copies[1].ContentType)
+	assert.Equal(t, types.TaggingDirectiveReplace, mock.copies[1].TaggingDirective)
+	assert.Equal(t, "t=1", *mock.copies[1].Tagging)
+}
+
+func TestCopyObject_KeepsMetadataWhenReplacingContentType(t *testing.T) {
+	mock := &S3Mock{head: &s3.HeadObjectOutput{ContentType: aws.String("application/octet-stream"), CacheControl: aws.String("max-age=60"), Metadata: map[string]string{"owner": "team"}}}
+
+	assert.NoError(t, CopyObject(context.Background(), mock, "source", "a.csv", "target", "b.csv", WithContentType("text/csv")))
+
+	assert.Equal(t, types.MetadataDirectiveReplace, mock.copies[0].MetadataDirective)
+	assert.Equal(t, "text/csv", *mock.copies[0].ContentType)
+	assert.Equal(t, "max-age=60", *mock.copies[0].CacheControl)
+	assert.Equal(t, map[string]string{"owner": "team"}, mock.copies[0].Metadata)
+
+	mock.head = nil
+	assert.ErrorContains(t, CopyObject(context.Background(), mock, "source", "missing.csv", "target", "b.csv", WithContentType("text/csv")), "failed to read metadata of source/missing.csv")
+	assert.Len(t, mock.copies, 1)
+}
+
+func TestDeleteObjects_BatchesAndReportsFailedKeys(t *testing.T) {
+	mock := &S3Mock{failKeys: map[string]bool{"key-3": true, "key-1500": true}}
+	keys := make([]string, 2001)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+	}
+
+	err := DeleteObjects(context.Background(), mock, "bucket", keys)
+
+	assert.Len(t, mock.deletes, 3)
+	assert.Len(t, mock.deletes[0].Delete.Objects, 1000)
+	assert.Len(t, mock.deletes[2].Delete.Objects, 1)
+	assert.ErrorContains(t, err, "failed to delete key-3: AccessDenied: Access Denied")
+	assert.ErrorContains(t, err, "failed to delete key-1500")
+}
+
+func TestHeadObject(t *testing.T) {
+	mock := &S3Mock{}
+	_, err := HeadObject(context.Background(), mock, "bucket", "missing.txt")
+	assert.ErrorIs(t, err, ErrObjectNotFound)
+
+	mock.head = &s3.HeadObjectOutput{ContentLength: aws.Int64(42), ContentType: aws.String("text/plain"), ETag: aws.String(`"etag"`), Metadata: map[string]string{"owner": "team"}, ServerSideEncryption: types.ServerSideEncryptionAwsKms}
+	info, err := HeadObject(context.Background(), mock, "bucket", "file.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, ObjectInfo{Key: "file.txt", Size: 42, ContentType: "text/plain", ETag: `"etag"`, Metadata: map[string]string{"owner": "team"}, ServerSideEncryption: "aws:kms"}, *info)
+}
+
+func TestObjectTags(t *testing.T) {
+	mock := &S3Mock{}
+
+	assert.NoError(t, PutObjectTags(context.Background(), mock, "bucket", "file.txt", map[string]string{"env": "test"}))
+	tags, err := GetObjectTags(context.Background(), mock, "bucket", "file.txt")
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"env": "test"}, tags)
+}
@@ -0,0 +1,265 @@
+package awss3
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+type PresignPutObject interface {
+	PresignPutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error)
+}
+
+type PresignPostObject interface {
+	PresignPostObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.PresignPostOptions)) (*s3.PresignedPostRequest, error)
+}
+
+// Options of presigned uploads: any `ObjectOption`, or a `PresignConstraint` that only presigned uploads can enforce.
+type PresignOption interface {
+	applyPresign(*presignOptions)
+}
+
+// Limits what the client of a presigned upload may send, e.g. `WithContentLengthRange`.
+type PresignConstraint func(*presignOptions)
+
+func (c PresignConstraint) applyPresign(o *presignOptions) {
+	c(o)
+}
+
+func (option ObjectOption) applyPresign(o *presignOptions) {
+	option(&o.objectOptions)
+}
+
+type presignOptions struct {
+	objectOptions
+	contentMD5 string
+	minSize    int64
+	maxSize    int64
+	keyPrefix  *string
+}
+
+func newPresignOptions(options []PresignOption) presignOptions {
+	o := presignOptions{objectOptions: newObjectOptions(nil)}
+	for _, option := range options {
+		option.applyPresign(&o)
+	}
+	return o
+}
+
+// Pins the MD5 of the content of a presigned PUT, verified by S3.
+func WithContentMD5(sum []byte) PresignConstraint {
+	return func(o *presignOptions) {
+		o.contentMD5 = base64.StdEncoding.EncodeToString(sum)
+	}
+}
+
+// Limits the size of objects uploaded with a presigned POST.
+func WithContentLengthRange(minBytes int64, maxBytes int64) PresignConstraint {
+	return func(o *presignOptions) {
+		o.minSize, o.maxSize = minBytes, maxBytes
+	}
+}
+
+// Lets the form of a presigned POST choose any key starting with `prefix`, instead of only the presigned key.
+func WithKeyStartsWith(prefix string) PresignConstraint {
+	return func(o *presignOptions) {
+		o.keyPrefix = &prefix
+	}
+}
+
+// A presigned PUT request. The upload must send `Headers`, since their values are part of the signature.
+type PresignedUpload struct {
+	URL     string
+	Method  string
+	Headers map[string]string
+}
+
+// GeneratePresignedPutURL presigns a PUT upload of `objectKey`, pinning the content type, content MD5, metadata, tags and encryption from `options`.
+// Returns an error with `WithContentLengthRange` or `WithKeyStartsWith`, since a PUT can't enforce them.
+//
+// Usage:
+// ```go
+//
+//	upload, err := awss3.GeneratePresignedPutURL(ctx, s3.NewPresignClient(client), "documents", "applications/123.pdf", 15*time.Minute,
+//		awss3.WithContentType("application/pdf"), awss3.WithContentMD5(sum[:]))
+//
+// ```
+func GeneratePresignedPutURL(ctx context.Context, api PresignPutObject, bucketName string, objectKey string, expiry time.Duration, options ...PresignOption) (*PresignedUpload, error) {
+	o := newPresignOptions(options)
+	if o.maxSize > 0 || o.keyPrefix != nil {
+		return nil, errors.New("a presigned PUT can't limit the content length or key, use GeneratePresignedPost")
+	}
+	encryption, keyId := o.encryption()
+
+	input := &s3.PutObjectInput{
+		Bucket:               aws.String(bucketName),
+		Key:                  aws.String(objectKey),
+		Metadata:             o.metadata,
+		Tagging:              o.tagging(),
+		ServerSideEncryption: encryption,
+		SSEKMSKeyId:          keyId,
+	}
+	if o.contentType != "" {
+		input.ContentType = aws.String(o.contentType)
+	}
+	if o.contentMD5 != "" {
+		input.ContentMD5 = aws.String(o.contentMD5)
+	}
+
+	presignOptions := []func(*s3.PresignOptions){s3.WithPresignExpires(expiry)}
+	if o.contentType != "" {
+		presignOptions = append(presignOptions, signContentType(o.contentType))
+	}
+	request, err := api.PresignPutObject(ctx, input, presignOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate presigned URL: %w", err)
+	}
+
+	headers := make(map[string]string, len(request.SignedHeader))
+	for name := range request.SignedHeader {
+		// The client sets Host from the URL
+		if name != "Host" {
+			headers[name] = request.SignedHeader.Get(name)
+		}
+	}
+	return &PresignedUpload{URL: request.URL, Method: request.Method, Headers: headers}, nil
+}
+
+// The SDK removes Content-Type from requests without a body, so presigned PUTs would accept any content type.
+// Adds it back after that middleware, so it's signed.
+func signContentType(contentType string) func(*s3.PresignOptions) {
+	return func(po *s3.PresignOptions) {
+		po.ClientOptions = append(po.ClientOptions, func(o *s3.Options) {
+			o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+				return stack.Build.Add(middleware.BuildMiddlewareFunc("SignContentType", func(ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler) (middleware.BuildOutput, middleware.Metadata, error) {
+					if request, ok := in.Request.(*smithyhttp.Request); ok {
+						request.Header.Set("Content-Type", contentType)
+					}
+					return next.HandleBuild(ctx, in)
+				}), middleware.After)
+			})
+		})
+	}
+}
+
+// GeneratePresignedPost presigns a browser form upload of `objectKey`. The form must send the returned `Values` as fields before the file.
+// The content type, metadata, tags and encryption from `options` are added to the fields and required by the policy,
+// `WithContentLengthRange` limits the size and `WithKeyStartsWith` allows other keys under a prefix, e.g. with `objectKey` "uploads/${filename}".
+// Returns an error with `WithContentMD5`, since S3 doesn't verify the MD5 of POST uploads.
+//
+// Usage:
+// ```go
+//
+//	post, err := awss3.GeneratePresignedPost(ctx, s3.NewPresignClient(client), "documents", "uploads/123/${filename}", 15*time.Minute,
+//		awss3.WithKeyStartsWith("uploads/123/"), awss3.WithContentLengthRange(1, 10*1024*1024))
+//
+// ```
+func GeneratePresignedPost(ctx context.Context, api PresignPostObject, bucketName string, objectKey string, expiry time.Duration, options ...PresignOption) (*s3.PresignedPostRequest, error) {
+	o := newPresignOptions(options)
+	if o.contentMD5 != "" {
+		return nil, errors.New("a presigned POST can't pin the content MD5, use GeneratePresignedPutURL")
+	}
+
+	fields := map[string]string{}
+	if o.contentType != "" {
+		fields["Content-Type"] = o.contentType
+	}
+	for key, value := range o.metadata {
+		fields["x-amz-meta-"+key] = value
+	}
+	if len(o.tags) > 0 {
+		tagging, err := postTagging(o.tags)
+		if err != nil {
+			return nil, err
+		}
+		fields["tagging"] = tagging
+	}
+	if encryption, keyId := o.encryption(); encryption != "" {
+		fields["x-amz-server-side-encryption"] = string(encryption)
+		if keyId != nil {
+			fields["x-amz-server-side-encryption-aws-kms-key-id"] = *keyId
+		}
+	}
+
+	var conditions []any
+	for key, value := range fields {
+		conditions = append(conditions, map[string]string{key: value})
+	}
+	if o.maxSize > 0 {
+		conditions = append(conditions, []any{"content-length-range", o.minSize, o.maxSize})
+	}
+	if o.keyPrefix != nil {
+		conditions = append(conditions, []any{"starts-with", "$key", *o.keyPrefix})
+	}
+
+	request, err := api.PresignPostObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(objectKey),
+	}, func(po *s3.PresignPostOptions) {
+		po.Expires = expiry
+		po.Conditions = conditions
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate presigned POST: %w", err)
+	}
+
+	for key, value := range fields {
+		request.Values[key] = value
+	}
+	return request, nil
+}
+
+// POST uploads take tags as an XML document, instead of the URL encoded query of PUT.
+func postTagging(tags map[string]string) (string, error) {
+	type tag struct {
+		Key   string
+		Value string
+	}
+	var tagging struct {
+		XMLName xml.Name `xml:"Tagging"`
+		TagSet  []tag    `xml:"TagSet>Tag"`
+	}
+	for _, key := range slices.Sorted(maps.Keys(tags)) {
+		tagging.TagSet = append(tagging.TagSet, tag{key, tags[key]})
+	}
+	document, err := xml.Marshal(tagging)
+	return string(document), err
+}
+
+// GeneratePresignedInlineURL presigns a GET of `objectKey` displayed by the browser, instead of downloaded like with `GeneratePresignedURL`.
+func GeneratePresignedInlineURL(ctx context.Context, api PresignObject, bucketName string, objectKey string, mimeType string, fileName string, expiry time.Duration) (string, error) {
+	return presignGet(ctx, api, bucketName, objectKey, mimeType, "inline", fileName, expiry)
+}
+
+// An empty `mimeType` or `fileName` keeps the content type or disposition of the object.
+func presignGet(ctx context.Context, api PresignObject, bucketName string, objectKey string, mimeType string, disposition string, fileName string, expiry time.Duration) (string, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(objectKey),
+	}
+	if mimeType != "" {
+		input.ResponseContentType = aws.String(mimeType)
+	}
+	if fileName != "" {
+		input.ResponseContentDisposition = aws.String(fmt.Sprintf("%s; filename=\"%s\"", disposition, fileName))
+	}
+
+	// Generer en presigned URL
+	presignedURL, err := api.PresignGetObject(ctx, input, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate presigned URL: %w", err)
+	}
+
+	return presignedURL.URL, nil
+}
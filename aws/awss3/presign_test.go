@@ -0,0 +1,102 @@
+package awss3
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+func presignClient() *s3.PresignClient {
+	return s3.NewPresignClient(s3.New(s3.Options{
+		Region:      "eu-north-1",
+		Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+	}))
+}
+
+func TestGeneratePresignedPutURL_PinsHeaders(t *testing.T) {
+	sum := md5.Sum([]byte("%PDF"))
+
+	upload, err := GeneratePresignedPutURL(context.Background(), presignClient(), "documents", "applications/123.pdf", 15*time.Minute,
+		WithContentType("application/pdf"), WithContentMD5(sum[:]), WithMetadata(map[string]string{"case": "123"}), WithKMSEncryption("key-id"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "PUT", upload.Method)
+	assert.Contains(t, upload.URL, "https://documents.s3.eu-north-1.amazonaws.com/applications/123.pdf?")
+	assert.Equal(t, map[string]string{
+		"Content-Type":                 "application/pdf",
+		"Content-Md5":                  base64.StdEncoding.EncodeToString(sum[:]),
+		"X-Amz-Meta-Case":              "123",
+		"X-Amz-Server-Side-Encryption": "aws:kms",
+		"X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id": "key-id",
+	}, upload.Headers)
+
+	query, _ := url.ParseQuery(upload.URL[len("https://documents.s3.eu-north-1.amazonaws.com/applications/123.pdf?"):])
+	assert.Equal(t, "900", query.Get("X-Amz-Expires"))
+	assert.Contains(t, query.Get("X-Amz-SignedHeaders"), "content-md5;content-type")
+}
+
+func TestGeneratePresignedPost_AddsConditions(t *testing.T) {
+	post, err := GeneratePresignedPost(context.Background(), presignClient(), "documents", "uploads/123/${filename}", 10*time.Minute,
+		WithKeyStartsWith("uploads/123/"), WithContentLengthRange(1, 1024), WithContentType("image/png"), WithMetadata(map[string]string{"case": "123"}))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://documents.s3.eu-north-1.amazonaws.com", post.URL)
+	assert.Equal(t, "uploads/123/${filename}", post.Values["key"])
+	assert.Equal(t, "image/png", post.Values["Content-Type"])
+	assert.Equal(t, "123", post.Values["x-amz-meta-case"])
+
+	document, _ := base64.StdEncoding.DecodeString(post.Values["policy"])
+	var policy struct{ Conditions []any }
+	assert.NoError(t, json.Unmarshal(document, &policy))
+	assert.Contains(t, policy.Conditions, []any{"content-length-range", 1.0, 1024.0})
+	assert.Contains(t, policy.Conditions, []any{"starts-with", "$key", "uploads/123/"})
+	assert.Contains(t, policy.Conditions, map[string]any{"Content-Type": "image/png"})
+	assert.Contains(t, policy.Conditions, map[string]any{"x-amz-meta-case": "123"})
+	assert.NotContains(t, policy.Conditions, map[string]any{"key": "uploads/123/${filename}"})
+}
+
+func TestGeneratePresignedPost_AddsTags(t *testing.T) {
+	post, err := GeneratePresignedPost(context.Background(), presignClient(), "documents", "uploads/123.pdf", time.Minute, WithTags(map[string]string{"env": "test", "case": "<123>"}))
+
+	assert.NoError(t, err)
+	tagging := `<Tagging><TagSet><Tag><Key>case</Key><Value>&lt;123&gt;</Value></Tag><Tag><Key>env</Key><Value>test</Value></Tag></TagSet></Tagging>`
+	assert.Equal(t, tagging, post.Values["tagging"])
+	document, _ := base64.StdEncoding.DecodeString(post.Values["policy"])
+	var policy struct{ Conditions []any }
+	assert.NoError(t, json.Unmarshal(document, &policy))
+	assert.Contains(t, policy.Conditions, map[string]any{"tagging": tagging})
+}
+
+func TestGeneratePresigned_RejectsConstraintsTheyCantEnforce(t *testing.T) {
+	sum := md5.Sum([]byte("%PDF"))
+
+	_, err := GeneratePresignedPutURL(context.Background(), presignClient(), "documents", "a.pdf", time.Minute, WithContentLengthRange(1, 1024))
+	assert.ErrorContains(t, err, "use GeneratePresignedPost")
+	_, err = GeneratePresignedPutURL(context.Background(), presignClient(), "documents", "a.pdf", time.Minute, WithKeyStartsWith("uploads/"))
+	assert.ErrorContains(t, err, "use GeneratePresignedPost")
+	_, err = GeneratePresignedPost(context.Background(), presignClient(), "documents", "a.pdf", time.Minute, WithContentMD5(sum[:]))
+	assert.ErrorContains(t, err, "use GeneratePresignedPutURL")
+}
+
+func TestGeneratePresignedURL_Dispositions(t *testing.T) {
+	attachment, err := GeneratePresignedURL(context.Background(), presignClient(), "documents", "a.pdf", "application/pdf", "report.pdf", time.Minute)
+	assert.NoError(t, err)
+	inline, err := GeneratePresignedInlineURL(context.Background(), presignClient(), "documents", "a.pdf", "application/pdf", "report.pdf", time.Minute)
+	assert.NoError(t, err)
+
+	query := func(u string) url.Values {
+		parsed, _ := url.Parse(u)
+		return parsed.Query()
+	}
+	assert.Equal(t, `attachment; filename="report.pdf"`, query(attachment).Get("response-content-disposition"))
+	assert.Equal(t, `inline; filename="report.pdf"`, query(inline).Get("response-content-disposition"))
+	assert.Equal(t, "application/pdf", query(inline).Get("response-content-type"))
+}
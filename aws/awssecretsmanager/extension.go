@@ -3,6 +3,7 @@ package awssecretsmanager
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,8 +16,6 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
-var sessionToken = os.Getenv("AWS_SESSION_TOKEN")
-
 type GetSecretFromExtensionApi interface {
 	GetSecret(ctx context.Context, name string, version *string) (*SecretData, error)
 }
@@ -37,7 +36,10 @@ type SecretsManagerExtensionClient struct {
 	tracing    bool
 }
 
-var _ GetSecretFromExtensionApi = (*SecretsManagerExtensionClient)(nil)
+var (
+	_ GetSecretFromExtensionApi = (*SecretsManagerExtensionClient)(nil)
+	_ SecretSource              = (*SecretsManagerExtensionClient)(nil)
+)
 
 func NewExtensionClient(tracing bool) *SecretsManagerExtensionClient {
 	httpClient := &http.Client{
@@ -59,6 +61,36 @@ func NewExtensionClient(tracing bool) *SecretsManagerExtensionClient {
 }
 
 func (p *SecretsManagerExtensionClient) GetSecret(ctx context.Context, name string, version *string) (*SecretData, error) {
+	if version != nil {
+		return p.getSecret(ctx, name, Version{Id: *version})
+	}
+	return p.getSecret(ctx, name, Version{})
+}
+
+func (p *SecretsManagerExtensionClient) GetSecretVersion(ctx context.Context, name string, version Version) (*Secret, error) {
+	data, err := p.getSecret(ctx, name, version)
+	if err != nil {
+		return nil, err
+	}
+	return data.secret(), nil
+}
+
+// Reads the secrets one at a time, since the extension has no batch endpoint, but from its cache.
+func (p *SecretsManagerExtensionClient) BatchGetSecrets(ctx context.Context, names []string) (map[string]*Secret, error) {
+	secrets := make(map[string]*Secret, len(names))
+	var errs []error
+	for _, name := range names {
+		secret, err := p.GetSecretVersion(ctx, name, Version{})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		secrets[name] = secret
+	}
+	return secrets, errors.Join(errs...)
+}
+
+func (p *SecretsManagerExtensionClient) getSecret(ctx context.Context, name string, version Version) (*SecretData, error) {
 	// Define the URL
 	parsedURL, err := url.Parse("http://localhost:2773/secretsmanager/get")
 	if err != nil {
@@ -66,8 +98,11 @@ func (p *SecretsManagerExtensionClient) GetSecret(ctx context.Context, name stri
 	}
 	query := parsedURL.Query()
 	query.Set("secretId", name)
-	if version != nil {
-		query.Set("versionId", *version)
+	if version.Id != "" {
+		query.Set("versionId", version.Id)
+	}
+	if version.Stage != "" {
+		query.Set("versionStage", version.Stage)
 	}
 	parsedURL.RawQuery = query.Encode()
 
@@ -77,7 +112,8 @@ func (p *SecretsManagerExtensionClient) GetSecret(ctx context.Context, name stri
 		return nil, err
 	}
 
-	req.Header.Add("X-Aws-Parameters-Secrets-Token", sessionToken)
+	// Read for each request, since the token changes when SnapStart restores the function
+	req.Header.Add("X-Aws-Parameters-Secrets-Token", os.Getenv("AWS_SESSION_TOKEN"))
 
 	// Call endpoint
 	response, err := p.httpClient.Do(req)
@@ -107,6 +143,18 @@ func (p *SecretsManagerExtensionClient) GetSecret(ctx context.Context, name stri
 	return &container, nil
 }
 
+func (s *SecretData) secret() *Secret {
+	return &Secret{
+		ARN:           s.ARN,
+		Name:          s.Name,
+		VersionId:     s.VersionId,
+		VersionStages: s.VersionStages,
+		CreatedDate:   s.CreatedDate,
+		SecretString:  s.SecretString,
+		SecretBinary:  s.SecretBinary,
+	}
+}
+
 func ReadSecretsManagerSecretFromExtension(ctx context.Context, name string, api GetSecretFromExtensionApi, version *string) (*SecretData, error) {
 	return api.GetSecret(ctx, name, version)
 }
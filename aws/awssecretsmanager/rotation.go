@@ -0,0 +1,257 @@
+package awssecretsmanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/rs/zerolog/log"
+)
+
+// Steps of the Secrets Manager rotation protocol, each invoking the rotation Lambda once.
+const (
+	StepCreateSecret = "createSecret"
+	StepSetSecret    = "setSecret"
+	StepTestSecret   = "testSecret"
+	StepFinishSecret = "finishSecret"
+)
+
+// The event Secrets Manager invokes a rotation Lambda with.
+type RotationEvent struct {
+	Step               string `json:"Step"`
+	SecretId           string `json:"SecretId"`
+	ClientRequestToken string `json:"ClientRequestToken"`
+	RotationToken      string `json:"RotationToken,omitempty"`
+}
+
+// The versions of a secret during rotation.
+type RotationSecrets struct {
+	Current *Secret
+	Pending *Secret
+	// Nil before the first rotation
+	Previous *Secret
+}
+
+// How to rotate a kind of secret, e.g. a database password or an API key.
+// Each step must be safe to retry, since Secrets Manager retries failed steps.
+type RotationStrategy interface {
+	// Returns the value of the new version, based on the current version, e.g. with a new password
+	CreateSecret(ctx context.Context, current *Secret) (string, error)
+	// Applies the pending version in the service the secret is for, e.g. changes the password in the database
+	SetSecret(ctx context.Context, secrets RotationSecrets) error
+	// Verifies that the pending version works in the service
+	TestSecret(ctx context.Context, pending *Secret) error
+}
+
+type DescribeSecretApi interface {
+	DescribeSecret(ctx context.Context, params *secretsmanager.DescribeSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.DescribeSecretOutput, error)
+}
+
+type PutSecretValueApi interface {
+	PutSecretValue(ctx context.Context, params *secretsmanager.PutSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.PutSecretValueOutput, error)
+}
+
+type UpdateSecretVersionStageApi interface {
+	UpdateSecretVersionStage(ctx context.Context, params *secretsmanager.UpdateSecretVersionStageInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.UpdateSecretVersionStageOutput, error)
+}
+
+type RotationApi interface {
+	DescribeSecretApi
+	GetSecretValueApi
+	PutSecretValueApi
+	UpdateSecretVersionStageApi
+}
+
+// Implements the four steps of the Secrets Manager rotation protocol, delegating to a `RotationStrategy`.
+//
+// Usage:
+// ```go
+//
+//	lambdaruntime.StartWithContext(ctx, func(ctx context.Context) func(context.Context, awssecretsmanager.RotationEvent) (any, error) {
+//		client := awssecretsmanager.NewClient(true)
+//		strategy := postgres.NewSingleUserStrategy(awssecretsmanager.RandomPassword(client, 32, postgres.ExcludeCharacters))
+//		return awssecretsmanager.NewRotator(client, strategy).Handle
+//	}, true)
+//
+// ```
+type Rotator struct {
+	client   RotationApi
+	strategy RotationStrategy
+}
+
+func NewRotator(client RotationApi, strategy RotationStrategy) *Rotator {
+	return &Rotator{client, strategy}
+}
+
+// Lambda handler running the step of `event`.
+func (r *Rotator) Handle(ctx context.Context, event RotationEvent) (any, error) {
+	return nil, r.Rotate(ctx, event)
+}
+
+// Runs the step of `event`.
+func (r *Rotator) Rotate(ctx context.Context, event RotationEvent) error {
+	logger := log.With().Str("secret_id", event.SecretId).Str("step", event.Step).Str("version_id", event.ClientRequestToken).Logger()
+
+	description, err := r.client.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{SecretId: aws.String(event.SecretId)})
+	if err != nil {
+		return err
+	}
+	if !aws.ToBool(description.RotationEnabled) {
+		return fmt.Errorf("secret %s is not enabled for rotation", event.SecretId)
+	}
+
+	stages, ok := description.VersionIdsToStages[event.ClientRequestToken]
+	if !ok {
+		return fmt.Errorf("secret version %s has no stage for rotation of secret %s", event.ClientRequestToken, event.SecretId)
+	}
+	if slices.Contains(stages, StageCurrent) {
+		logger.Info().Msg("Secret version is already set as AWSCURRENT")
+		return nil
+	}
+	if !slices.Contains(stages, StagePending) {
+		return fmt.Errorf("secret version %s not set as AWSPENDING for rotation of secret %s", event.ClientRequestToken, event.SecretId)
+	}
+
+	switch event.Step {
+	case StepCreateSecret:
+		err = r.createSecret(ctx, event)
+	case StepSetSecret:
+		err = r.setSecret(ctx, event)
+	case StepTestSecret:
+		err = r.testSecret(ctx, event)
+	case StepFinishSecret:
+		err = r.finishSecret(ctx, event, description.VersionIdsToStages)
+	default:
+		return fmt.Errorf("invalid rotation step %q", event.Step)
+	}
+	if err != nil {
+		return fmt.Errorf("%s of secret %s: %w", event.Step, event.SecretId, err)
+	}
+
+	logger.Info().Msg("Finished rotation step")
+	return nil
+}
+
+func (r *Rotator) createSecret(ctx context.Context, event RotationEvent) error {
+	current, err := getSecretVersion(ctx, r.client, event.SecretId, WithStage(StageCurrent))
+	if err != nil {
+		return err
+	}
+
+	// A retried step has already created the pending version
+	_, err = getSecretVersion(ctx, r.client, event.SecretId, Version{Id: event.ClientRequestToken, Stage: StagePending})
+	if err == nil {
+		return nil
+	}
+	var notFound *types.ResourceNotFoundException
+	if !errors.As(err, &notFound) {
+		return err
+	}
+
+	value, err := r.strategy.CreateSecret(ctx, current)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.PutSecretValue(ctx, &secretsmanager.PutSecretValueInput{
+		SecretId:           aws.String(event.SecretId),
+		ClientRequestToken: aws.String(event.ClientRequestToken),
+		SecretString:       aws.String(value),
+		VersionStages:      []string{StagePending},
+		RotationToken:      optionalString(event.RotationToken),
+	})
+	return err
+}
+
+func (r *Rotator) setSecret(ctx context.Context, event RotationEvent) error {
+	secrets, err := r.rotationSecrets(ctx, event)
+	if err != nil {
+		return err
+	}
+	return r.strategy.SetSecret(ctx, secrets)
+}
+
+func (r *Rotator) testSecret(ctx context.Context, event RotationEvent) error {
+	pending, err := getSecretVersion(ctx, r.client, event.SecretId, Version{Id: event.ClientRequestToken, Stage: StagePending})
+	if err != nil {
+		return err
+	}
+	return r.strategy.TestSecret(ctx, pending)
+}
+
+func (r *Rotator) finishSecret(ctx context.Context, event RotationEvent, versions map[string][]string) error {
+	var current string
+	for version, stages := range versions {
+		if slices.Contains(stages, StageCurrent) {
+			current = version
+		}
+	}
+
+	_, err := r.client.UpdateSecretVersionStage(ctx, &secretsmanager.UpdateSecretVersionStageInput{
+		SecretId:            aws.String(event.SecretId),
+		VersionStage:        aws.String(StageCurrent),
+		MoveToVersionId:     aws.String(event.ClientRequestToken),
+		RemoveFromVersionId: optionalString(current),
+	})
+	return err
+}
+
+func (r *Rotator) rotationSecrets(ctx context.Context, event RotationEvent) (RotationSecrets, error) {
+	current, err := getSecretVersion(ctx, r.client, event.SecretId, WithStage(StageCurrent))
+	if err != nil {
+		return RotationSecrets{}, err
+	}
+	pending, err := getSecretVersion(ctx, r.client, event.SecretId, Version{Id: event.ClientRequestToken, Stage: StagePending})
+	if err != nil {
+		return RotationSecrets{}, err
+	}
+	previous, err := getSecretVersion(ctx, r.client, event.SecretId, WithStage(StagePrevious))
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		previous, err = nil, nil
+	}
+	if err != nil {
+		return RotationSecrets{}, err
+	}
+
+	return RotationSecrets{Current: current, Pending: pending, Previous: previous}, nil
+}
+
+type GetRandomPasswordApi interface {
+	GetRandomPassword(ctx context.Context, params *secretsmanager.GetRandomPasswordInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetRandomPasswordOutput, error)
+}
+
+// Generates passwords for new versions of secrets.
+type PasswordGenerator func(ctx context.Context) (string, error)
+
+// Generates passwords of `length` characters with Secrets Manager's `GetRandomPassword`, without `excludeCharacters`.
+func RandomPassword(client GetRandomPasswordApi, length int64, excludeCharacters string) PasswordGenerator {
+	return func(ctx context.Context) (string, error) {
+		output, err := client.GetRandomPassword(ctx, &secretsmanager.GetRandomPasswordInput{
+			PasswordLength:    aws.Int64(length),
+			ExcludeCharacters: optionalString(excludeCharacters),
+		})
+		if err != nil {
+			return "", err
+		}
+		return aws.ToString(output.RandomPassword), nil
+	}
+}
+
+// Returns the JSON secret with `fields` set, keeping its other fields. For `RotationStrategy.CreateSecret`, e.g. to set a new password.
+func SetSecretFields(secret *Secret, fields map[string]any) (string, error) {
+	var document map[string]any
+	if err := json.Unmarshal(secret.Bytes(), &document); err != nil {
+		return "", fmt.Errorf("%s: secret is not a valid JSON object: %w", secret.Name, err)
+	}
+	for key, value := range fields {
+		document[key] = value
+	}
+	data, err := json.Marshal(document)
+	return string(data), err
+}
@@ -0,0 +1,205 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/oslokommune/common-lib-go/aws/awssecretsmanager"
+	"github.com/oslokommune/common-lib-go/db"
+)
+
+// Suffix of the second user of the alternating users strategy.
+const cloneSuffix = "_clone"
+
+// Characters that can't be used in Postgres passwords set by the rotation strategies, or in connection strings.
+const ExcludeCharacters = `:/@"'\`
+
+// The configuration for connecting to the database of `credentials` with `db.New`.
+func DbConf(credentials awssecretsmanager.RDSCredentials) *db.DbConf {
+	return db.NewDbConf(credentials.Username, credentials.Password, credentials.Host, credentials.Port, credentials.DbName)
+}
+
+// Rotates the password of a Postgres user stored as `awssecretsmanager.RDSCredentials`, changing it with the credentials themselves.
+// Connections using the previous password fail after the rotation until they read the new secret.
+type SingleUser struct {
+	passwords awssecretsmanager.PasswordGenerator
+}
+
+var _ awssecretsmanager.RotationStrategy = (*SingleUser)(nil)
+
+func NewSingleUserStrategy(passwords awssecretsmanager.PasswordGenerator) *SingleUser {
+	return &SingleUser{passwords}
+}
+
+func (s *SingleUser) CreateSecret(ctx context.Context, current *awssecretsmanager.Secret) (string, error) {
+	password, err := s.passwords(ctx)
+	if err != nil {
+		return "", err
+	}
+	return awssecretsmanager.SetSecretFields(current, map[string]any{"password": password})
+}
+
+func (s *SingleUser) SetSecret(ctx context.Context, secrets awssecretsmanager.RotationSecrets) error {
+	pending, err := awssecretsmanager.DecodeSecret[awssecretsmanager.RDSCredentials](secrets.Pending)
+	if err != nil {
+		return err
+	}
+	if conn, err := connect(ctx, *pending); err == nil {
+		// Set by a previous attempt
+		conn.CloseConnection()
+		return nil
+	}
+
+	// The previous password is still in use if the last rotation failed after setting it
+	candidates := []*awssecretsmanager.Secret{secrets.Current}
+	if secrets.Previous != nil {
+		candidates = append(candidates, secrets.Previous)
+	}
+
+	var conn *db.PostgresConnection
+	for _, candidate := range candidates {
+		credentials, err := awssecretsmanager.DecodeSecret[awssecretsmanager.RDSCredentials](candidate)
+		if err != nil {
+			return err
+		}
+		credentials.Host, credentials.Port, credentials.DbName = pending.Host, pending.Port, pending.DbName
+		if conn, err = connect(ctx, *credentials); err == nil {
+			break
+		}
+	}
+	if conn == nil {
+		return fmt.Errorf("unable to log in to %s with the current or previous password of %s", pending.Host, pending.Username)
+	}
+	defer conn.CloseConnection()
+
+	return alterPassword(ctx, conn.Connection(), pending.Username, pending.Password)
+}
+
+func (s *SingleUser) TestSecret(ctx context.Context, pending *awssecretsmanager.Secret) error {
+	return testSecret(ctx, pending)
+}
+
+// Alternates between two Postgres users, e.g. `app` and `app_clone`, setting the password of the inactive user with the credentials of a master user.
+// Connections using the current user keep working during and after the rotation.
+//
+// The secret must contain the ARN of a secret with the master user's credentials in `masterarn`.
+// The clone user is created as a member of the original user's role, so it inherits its privileges.
+type AlternatingUsers struct {
+	source    awssecretsmanager.SecretSource
+	passwords awssecretsmanager.PasswordGenerator
+}
+
+var _ awssecretsmanager.RotationStrategy = (*AlternatingUsers)(nil)
+
+// Reads the master user's credentials from `source`.
+func NewAlternatingUsersStrategy(source awssecretsmanager.SecretSource, passwords awssecretsmanager.PasswordGenerator) *AlternatingUsers {
+	return &AlternatingUsers{source, passwords}
+}
+
+func (s *AlternatingUsers) CreateSecret(ctx context.Context, current *awssecretsmanager.Secret) (string, error) {
+	credentials, err := awssecretsmanager.DecodeSecret[awssecretsmanager.RDSCredentials](current)
+	if err != nil {
+		return "", err
+	}
+
+	username := credentials.Username + cloneSuffix
+	if base, found := strings.CutSuffix(credentials.Username, cloneSuffix); found {
+		username = base
+	}
+
+	password, err := s.passwords(ctx)
+	if err != nil {
+		return "", err
+	}
+	return awssecretsmanager.SetSecretFields(current, map[string]any{"username": username, "password": password})
+}
+
+func (s *AlternatingUsers) SetSecret(ctx context.Context, secrets awssecretsmanager.RotationSecrets) error {
+	pending, err := awssecretsmanager.DecodeSecret[awssecretsmanager.RDSCredentials](secrets.Pending)
+	if err != nil {
+		return err
+	}
+	if conn, err := connect(ctx, *pending); err == nil {
+		// Set by a previous attempt
+		conn.CloseConnection()
+		return nil
+	}
+
+	current, err := awssecretsmanager.DecodeSecret[awssecretsmanager.RDSCredentials](secrets.Current)
+	if err != nil {
+		return err
+	}
+	if current.MasterARN == "" {
+		return fmt.Errorf("secret %s has no masterarn for the alternating users strategy", secrets.Current.Name)
+	}
+	master, err := awssecretsmanager.GetSecretAs[awssecretsmanager.RDSCredentials](ctx, s.source, current.MasterARN)
+	if err != nil {
+		return fmt.Errorf("reading master secret: %w", err)
+	}
+	master.Host, master.Port, master.DbName = pending.Host, pending.Port, pending.DbName
+
+	conn, err := connect(ctx, *master)
+	if err != nil {
+		return fmt.Errorf("unable to log in to %s as the master user: %w", pending.Host, err)
+	}
+	defer conn.CloseConnection()
+
+	var exists bool
+	if err := conn.Connection().QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = $1)", pending.Username).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return alterPassword(ctx, conn.Connection(), pending.Username, pending.Password)
+	}
+	return execFormatted(ctx, conn.Connection(), "CREATE ROLE %I WITH LOGIN PASSWORD %L IN ROLE %I", pending.Username, pending.Password, current.Username)
+}
+
+func (s *AlternatingUsers) TestSecret(ctx context.Context, pending *awssecretsmanager.Secret) error {
+	return testSecret(ctx, pending)
+}
+
+func testSecret(ctx context.Context, pending *awssecretsmanager.Secret) error {
+	credentials, err := awssecretsmanager.DecodeSecret[awssecretsmanager.RDSCredentials](pending)
+	if err != nil {
+		return err
+	}
+	conn, err := connect(ctx, *credentials)
+	if err != nil {
+		return fmt.Errorf("unable to log in to %s with the pending secret: %w", credentials.Host, err)
+	}
+	defer conn.CloseConnection()
+
+	_, err = conn.Connection().ExecContext(ctx, "SELECT NOW()")
+	return err
+}
+
+func connect(ctx context.Context, credentials awssecretsmanager.RDSCredentials) (*db.PostgresConnection, error) {
+	conn := db.New(*DbConf(credentials))
+	if err := conn.Connection().PingContext(ctx); err != nil {
+		conn.CloseConnection()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func alterPassword(ctx context.Context, conn *sql.DB, username, password string) error {
+	return execFormatted(ctx, conn, "ALTER USER %I WITH PASSWORD %L", username, password)
+}
+
+// Executes a statement with identifiers and literals quoted by Postgres' `format`, since DDL statements can't take parameters.
+func execFormatted(ctx context.Context, conn *sql.DB, format string, args ...any) error {
+	placeholders := make([]string, len(args))
+	for i := range args {
+		placeholders[i] = fmt.Sprintf("$%d::text", i+2)
+	}
+
+	var statement string
+	query := fmt.Sprintf("SELECT format($1::text, %s)", strings.Join(placeholders, ", "))
+	if err := conn.QueryRowContext(ctx, query, append([]any{format}, args...)...).Scan(&statement); err != nil {
+		return err
+	}
+	_, err := conn.ExecContext(ctx, statement)
+	return err
+}
@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/oslokommune/common-lib-go/aws/awssecretsmanager"
+	fake "github.com/oslokommune/common-lib-go/aws/awssecretsmanager/testing"
+	"github.com/oslokommune/common-lib-go/db"
+	dbtesting "github.com/oslokommune/common-lib-go/db/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// Master credentials of a Postgres container, skipping the test if Docker isn't available.
+func containerizedPostgres(t *testing.T) awssecretsmanager.RDSCredentials {
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+	conf := db.NewDbConf("postgres", "postgres", "localhost", 5432, "postgres")
+	dbtesting.ContainerizedPostgres(t, conf)
+	return awssecretsmanager.RDSCredentials{Engine: "postgres", Host: conf.Host, Port: conf.Port, Username: conf.Username, Password: conf.Password, DbName: conf.Database}
+}
+
+// Creates the role `username` with `password` as the master user, dropping it and `dropAlso` when the test ends.
+func createRole(t *testing.T, master awssecretsmanager.RDSCredentials, username, password string, dropAlso ...string) awssecretsmanager.RDSCredentials {
+	conn, err := connect(context.Background(), master)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer conn.CloseConnection()
+	for _, role := range append([]string{username}, dropAlso...) {
+		assert.NoError(t, execFormatted(context.Background(), conn.Connection(), "DROP ROLE IF EXISTS %I", role))
+	}
+	assert.NoError(t, execFormatted(context.Background(), conn.Connection(), "CREATE ROLE %I WITH LOGIN PASSWORD %L", username, password))
+
+	t.Cleanup(func() {
+		conn, err := connect(context.Background(), master)
+		if err != nil {
+			return
+		}
+		defer conn.CloseConnection()
+		for _, role := range append(dropAlso, username) {
+			_ = execFormatted(context.Background(), conn.Connection(), "DROP ROLE IF EXISTS %I", role)
+		}
+	})
+	return awssecretsmanager.RDSCredentials{Engine: "postgres", Host: master.Host, Port: master.Port, Username: username, Password: password, DbName: master.DbName}
+}
+
+func createSecret(t *testing.T, sm *fake.FakeSecretsManager, name string, credentials awssecretsmanager.RDSCredentials) string {
+	value, _ := json.Marshal(credentials)
+	output, err := sm.CreateSecret(context.Background(), &secretsmanager.CreateSecretInput{Name: aws.String(name), SecretString: aws.String(string(value))})
+	assert.NoError(t, err)
+	return *output.ARN
+}
+
+func rotate(t *testing.T, sm *fake.FakeSecretsManager, strategy awssecretsmanager.RotationStrategy, name string) error {
+	output, err := sm.RotateSecret(context.Background(), &secretsmanager.RotateSecretInput{SecretId: aws.String(name)})
+	assert.NoError(t, err)
+
+	rotator := awssecretsmanager.NewRotator(sm, strategy)
+	for _, step := range []string{awssecretsmanager.StepCreateSecret, awssecretsmanager.StepSetSecret, awssecretsmanager.StepTestSecret, awssecretsmanager.StepFinishSecret} {
+		if err := rotator.Rotate(context.Background(), awssecretsmanager.RotationEvent{Step: step, SecretId: name, ClientRequestToken: *output.VersionId}); err != nil {
+			return fmt.Errorf("%s: %w", step, err)
+		}
+	}
+	return nil
+}
+
+func assertLogin(t *testing.T, sm *fake.FakeSecretsManager, name string) awssecretsmanager.RDSCredentials {
+	credentials, err := awssecretsmanager.GetSecretAs[awssecretsmanager.RDSCredentials](context.Background(), awssecretsmanager.NewClientSource(sm), name)
+	assert.NoError(t, err)
+	conn, err := connect(context.Background(), *credentials)
+	if assert.NoError(t, err) {
+		conn.CloseConnection()
+	}
+	return *credentials
+}
+
+func TestSingleUser_RotatesPassword(t *testing.T) {
+	credentials := createRole(t, containerizedPostgres(t), "rotation_single", "initial")
+	sm := fake.NewFakeSecretsManager()
+	createSecret(t, sm, "app/db", credentials)
+
+	assert.NoError(t, rotate(t, sm, NewSingleUserStrategy(awssecretsmanager.RandomPassword(sm, 32, ExcludeCharacters)), "app/db"))
+
+	rotated := assertLogin(t, sm, "app/db")
+	assert.NotEqual(t, credentials.Password, rotated.Password)
+	_, err := connect(context.Background(), credentials)
+	assert.Error(t, err)
+}
+
+func TestAlternatingUsers_RotatesBetweenUsers(t *testing.T) {
+	master := containerizedPostgres(t)
+	sm := fake.NewFakeSecretsManager()
+	app := createRole(t, master, "rotation_app", "app", "rotation_app_clone")
+	app.MasterARN = createSecret(t, sm, "master/db", master)
+	createSecret(t, sm, "app/db", app)
+	strategy := NewAlternatingUsersStrategy(awssecretsmanager.NewClientSource(sm), awssecretsmanager.RandomPassword(sm, 32, ExcludeCharacters))
+
+	assert.NoError(t, rotate(t, sm, strategy, "app/db"))
+	assert.Equal(t, "rotation_app_clone", assertLogin(t, sm, "app/db").Username)
+	// The previous user keeps working
+	conn, err := connect(context.Background(), app)
+	if assert.NoError(t, err) {
+		conn.CloseConnection()
+	}
+
+	assert.NoError(t, rotate(t, sm, strategy, "app/db"))
+	assert.Equal(t, "rotation_app", assertLogin(t, sm, "app/db").Username)
+}
+
+func TestAlternatingUsers_AlternatesUsernames(t *testing.T) {
+	sm := fake.NewFakeSecretsManager()
+	strategy := NewAlternatingUsersStrategy(awssecretsmanager.NewClientSource(sm), awssecretsmanager.RandomPassword(sm, 16, ExcludeCharacters))
+
+	value, err := strategy.CreateSecret(context.Background(), &awssecretsmanager.Secret{SecretString: aws.String(`{"username":"app","password":"old","masterarn":"arn:master"}`)})
+	assert.NoError(t, err)
+	var clone awssecretsmanager.RDSCredentials
+	assert.NoError(t, json.Unmarshal([]byte(value), &clone))
+	assert.Equal(t, "app_clone", clone.Username)
+	assert.Equal(t, "arn:master", clone.MasterARN)
+	assert.Len(t, clone.Password, 16)
+	assert.NotContains(t, clone.Password, "@")
+
+	value, err = strategy.CreateSecret(context.Background(), &awssecretsmanager.Secret{SecretString: &value})
+	assert.NoError(t, err)
+	var original awssecretsmanager.RDSCredentials
+	assert.NoError(t, json.Unmarshal([]byte(value), &original))
+	assert.Equal(t, "app", original.Username)
+}
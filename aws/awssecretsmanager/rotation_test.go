@@ -0,0 +1,111 @@
+package awssecretsmanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	fake "github.com/oslokommune/common-lib-go/aws/awssecretsmanager/testing"
+	"github.com/stretchr/testify/assert"
+)
+
+// Rotates an API key in a service keeping the active keys in memory.
+type apiKeyStrategy struct {
+	keys    map[string]bool
+	setKeys int
+	failSet bool
+}
+
+func (s *apiKeyStrategy) CreateSecret(ctx context.Context, current *Secret) (string, error) {
+	return SetSecretFields(current, map[string]any{"key": "new-key"})
+}
+
+func (s *apiKeyStrategy) SetSecret(ctx context.Context, secrets RotationSecrets) error {
+	if s.failSet {
+		return errors.New("service unavailable")
+	}
+	var pending struct{ Key string }
+	_ = json.Unmarshal(secrets.Pending.Bytes(), &pending)
+	s.keys[pending.Key] = true
+	s.setKeys++
+	return nil
+}
+
+func (s *apiKeyStrategy) TestSecret(ctx context.Context, pending *Secret) error {
+	var secret struct{ Key string }
+	_ = json.Unmarshal(pending.Bytes(), &secret)
+	if !s.keys[secret.Key] {
+		return errors.New("key rejected")
+	}
+	return nil
+}
+
+func startRotation(t *testing.T, sm *fake.FakeSecretsManager, name string) string {
+	output, err := sm.RotateSecret(context.Background(), &secretsmanager.RotateSecretInput{SecretId: aws.String(name)})
+	assert.NoError(t, err)
+	return *output.VersionId
+}
+
+func rotate(rotator *Rotator, name, token string, steps ...string) error {
+	for _, step := range steps {
+		if err := rotator.Rotate(context.Background(), RotationEvent{Step: step, SecretId: name, ClientRequestToken: token}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var allSteps = []string{StepCreateSecret, StepSetSecret, StepTestSecret, StepFinishSecret}
+
+func TestRotator_RotatesThroughAllSteps(t *testing.T) {
+	sm := fake.NewFakeSecretsManager()
+	_, err := sm.CreateSecret(context.Background(), &secretsmanager.CreateSecretInput{Name: aws.String("app/api"), SecretString: aws.String(`{"key":"old-key","url":"https://api.example.com"}`)})
+	assert.NoError(t, err)
+	strategy := &apiKeyStrategy{keys: map[string]bool{"old-key": true}}
+	rotator := NewRotator(sm, strategy)
+
+	token := startRotation(t, sm, "app/api")
+	// Retried steps must be idempotent
+	assert.NoError(t, rotate(rotator, "app/api", token, StepCreateSecret, StepCreateSecret))
+	assert.NoError(t, rotate(rotator, "app/api", token, allSteps...))
+	assert.NoError(t, rotate(rotator, "app/api", token, StepFinishSecret))
+
+	source := NewClientSource(sm)
+	current, err := source.GetSecretVersion(context.Background(), "app/api", Version{})
+	assert.NoError(t, err)
+	assert.Equal(t, token, current.VersionId)
+	assert.JSONEq(t, `{"key":"new-key","url":"https://api.example.com"}`, *current.SecretString)
+
+	previous, err := source.GetSecretVersion(context.Background(), "app/api", WithStage(StagePrevious))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"key":"old-key","url":"https://api.example.com"}`, *previous.SecretString)
+}
+
+func TestRotator_KeepsCurrentVersionWhenStepFails(t *testing.T) {
+	sm := fake.NewFakeSecretsManager()
+	_, _ = sm.CreateSecret(context.Background(), &secretsmanager.CreateSecretInput{Name: aws.String("app/api"), SecretString: aws.String(`{"key":"old-key"}`)})
+	rotator := NewRotator(sm, &apiKeyStrategy{keys: map[string]bool{}, failSet: true})
+
+	token := startRotation(t, sm, "app/api")
+	err := rotate(rotator, "app/api", token, allSteps...)
+
+	assert.ErrorContains(t, err, "setSecret of secret app/api: service unavailable")
+	current, _ := GetSecretAs[map[string]string](context.Background(), NewClientSource(sm), "app/api")
+	assert.Equal(t, "old-key", (*current)["key"])
+}
+
+func TestRotator_RejectsInvalidEvents(t *testing.T) {
+	sm := fake.NewFakeSecretsManager()
+	_, _ = sm.CreateSecret(context.Background(), &secretsmanager.CreateSecretInput{Name: aws.String("app/api"), SecretString: aws.String(`{"key":"old-key"}`)})
+	rotator := NewRotator(sm, &apiKeyStrategy{keys: map[string]bool{}})
+
+	err := rotate(rotator, "app/api", "unknown", StepCreateSecret)
+	assert.ErrorContains(t, err, "not enabled for rotation")
+
+	token := startRotation(t, sm, "app/api")
+	assert.ErrorContains(t, rotate(rotator, "app/api", "unknown", StepCreateSecret), "has no stage for rotation")
+	assert.ErrorContains(t, rotate(rotator, "app/api", token, "deleteSecret"), `invalid rotation step "deleteSecret"`)
+}
@@ -0,0 +1,236 @@
+package awssecretsmanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// Staging labels Secrets Manager moves between versions during rotation.
+const (
+	StageCurrent  = "AWSCURRENT"
+	StagePrevious = "AWSPREVIOUS"
+	StagePending  = "AWSPENDING"
+)
+
+// Selects a version of a secret by ID, staging label or both. The zero value selects the current version.
+type Version struct {
+	Id    string
+	Stage string
+}
+
+// Selects the version with the staging label `stage`, e.g. `StagePrevious`.
+func WithStage(stage string) Version {
+	return Version{Stage: stage}
+}
+
+// Selects the version `id`.
+func WithVersionId(id string) Version {
+	return Version{Id: id}
+}
+
+// A version of a secret.
+type Secret struct {
+	ARN           string
+	Name          string
+	VersionId     string
+	VersionStages []string
+	CreatedDate   time.Time
+	// Set for secrets stored as text
+	SecretString *string
+	// Set for secrets stored as binary
+	SecretBinary []byte
+}
+
+// The secret as bytes, whether it's stored as text or binary.
+func (s *Secret) Bytes() []byte {
+	if s.SecretString != nil {
+		return []byte(*s.SecretString)
+	}
+	return s.SecretBinary
+}
+
+// Reads secrets from Secrets Manager, through the SDK with `NewClientSource` or the Lambda extension with `NewExtensionClient`.
+type SecretSource interface {
+	// Reads a version of the secret `name`
+	GetSecretVersion(ctx context.Context, name string, version Version) (*Secret, error)
+	// Reads the current version of the secrets `names`, keyed by the names given.
+	// If some secrets can't be read, the secrets read are returned with an error.
+	BatchGetSecrets(ctx context.Context, names []string) (map[string]*Secret, error)
+}
+
+type BatchGetSecretValueApi interface {
+	BatchGetSecretValue(ctx context.Context, params *secretsmanager.BatchGetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.BatchGetSecretValueOutput, error)
+}
+
+type SecretsManagerApi interface {
+	GetSecretValueApi
+	BatchGetSecretValueApi
+}
+
+// Maximum number of secrets in one `BatchGetSecretValue` call.
+const maxSecretsPerBatch = 20
+
+// Reads secrets with the Secrets Manager SDK client.
+type ClientSource struct {
+	client SecretsManagerApi
+}
+
+var _ SecretSource = (*ClientSource)(nil)
+
+func NewClientSource(client SecretsManagerApi) *ClientSource {
+	return &ClientSource{client}
+}
+
+func (s *ClientSource) GetSecretVersion(ctx context.Context, name string, version Version) (*Secret, error) {
+	return getSecretVersion(ctx, s.client, name, version)
+}
+
+func getSecretVersion(ctx context.Context, client GetSecretValueApi, name string, version Version) (*Secret, error) {
+	input := secretsmanager.GetSecretValueInput{
+		SecretId:     aws.String(name),
+		VersionId:    optionalString(version.Id),
+		VersionStage: optionalString(version.Stage),
+	}
+
+	output, err := getSecretValue(ctx, client, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Secret{
+		ARN:           aws.ToString(output.ARN),
+		Name:          aws.ToString(output.Name),
+		VersionId:     aws.ToString(output.VersionId),
+		VersionStages: output.VersionStages,
+		CreatedDate:   aws.ToTime(output.CreatedDate),
+		SecretString:  output.SecretString,
+		SecretBinary:  output.SecretBinary,
+	}, nil
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// Reads the secrets with `BatchGetSecretValue`, 20 at a time.
+func (s *ClientSource) BatchGetSecrets(ctx context.Context, names []string) (map[string]*Secret, error) {
+	secrets := make(map[string]*Secret, len(names))
+	var errs []error
+
+	for start := 0; start < len(names); start += maxSecretsPerBatch {
+		batch := names[start:min(start+maxSecretsPerBatch, len(names))]
+
+		input := secretsmanager.BatchGetSecretValueInput{SecretIdList: batch}
+		paginator := secretsmanager.NewBatchGetSecretValuePaginator(s.client, &input)
+		for paginator.HasMorePages() {
+			output, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, entry := range output.SecretValues {
+				secret := &Secret{
+					ARN:           aws.ToString(entry.ARN),
+					Name:          aws.ToString(entry.Name),
+					VersionId:     aws.ToString(entry.VersionId),
+					VersionStages: entry.VersionStages,
+					CreatedDate:   aws.ToTime(entry.CreatedDate),
+					SecretString:  entry.SecretString,
+					SecretBinary:  entry.SecretBinary,
+				}
+				// Secrets may be requested by name or ARN
+				key := secret.Name
+				if !slices.Contains(batch, key) {
+					key = secret.ARN
+				}
+				secrets[key] = secret
+			}
+			for _, e := range output.Errors {
+				errs = append(errs, fmt.Errorf("%s: %s: %s", aws.ToString(e.SecretId), aws.ToString(e.ErrorCode), aws.ToString(e.Message)))
+			}
+		}
+	}
+
+	return secrets, errors.Join(errs...)
+}
+
+// Reads the current version of the JSON secret `name` into a new `T`.
+//
+// Usage:
+// ```go
+//
+//	source := awssecretsmanager.NewClientSource(awssecretsmanager.NewClient(true))
+//	credentials, err := awssecretsmanager.GetSecretAs[awssecretsmanager.RDSCredentials](ctx, source, "my-app/db")
+//	if err != nil {
+//		return err
+//	}
+//	conn := db.New(*postgres.DbConf(*credentials))
+//
+// ```
+func GetSecretAs[T any](ctx context.Context, source SecretSource, name string) (*T, error) {
+	return GetSecretVersionAs[T](ctx, source, name, Version{})
+}
+
+// Reads a version of the JSON secret `name` into a new `T`, e.g. `WithStage(StagePending)` during rotation.
+func GetSecretVersionAs[T any](ctx context.Context, source SecretSource, name string, version Version) (*T, error) {
+	secret, err := source.GetSecretVersion(ctx, name, version)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeSecret[T](secret)
+}
+
+// Reads the current version of the JSON secrets `names` into new `T`s, keyed by name.
+// If some secrets can't be read or decoded, the others are returned with an error.
+func BatchGetSecretsAs[T any](ctx context.Context, source SecretSource, names []string) (map[string]*T, error) {
+	secrets, err := source.BatchGetSecrets(ctx, names)
+	if secrets == nil {
+		return nil, err
+	}
+
+	errs := []error{err}
+	values := make(map[string]*T, len(secrets))
+	for name, secret := range secrets {
+		value, err := DecodeSecret[T](secret)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		values[name] = value
+	}
+	return values, errors.Join(errs...)
+}
+
+// Reads the JSON secret into a new `T`.
+func DecodeSecret[T any](secret *Secret) (*T, error) {
+	var value T
+	if err := json.Unmarshal(secret.Bytes(), &value); err != nil {
+		return nil, fmt.Errorf("%s: secret is not a valid JSON %T: %w", secret.Name, value, err)
+	}
+	return &value, nil
+}
+
+// Credentials in the format of secrets managed by RDS and of the Secrets Manager rotation functions for RDS.
+// RDS managed secrets only contain `Username` and `Password`.
+type RDSCredentials struct {
+	Engine               string `json:"engine,omitempty"`
+	Host                 string `json:"host,omitempty"`
+	Port                 int    `json:"port,omitempty"`
+	Username             string `json:"username"`
+	Password             string `json:"password"`
+	DbName               string `json:"dbname,omitempty"`
+	DbInstanceIdentifier string `json:"dbInstanceIdentifier,omitempty"`
+	DbClusterIdentifier  string `json:"dbClusterIdentifier,omitempty"`
+	// The secret with the credentials of the user rotating this user, for the alternating users strategy
+	MasterARN string `json:"masterarn,omitempty"`
+}
@@ -0,0 +1,142 @@
+package awssecretsmanager
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// Secrets Manager with versions of secrets keyed by name and then by staging label.
+type SecretsManagerMock struct {
+	secrets map[string]map[string]string
+	batches [][]string
+}
+
+func (m *SecretsManagerMock) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	stage := aws.ToString(params.VersionStage)
+	if params.VersionId != nil {
+		stage = strings.TrimPrefix(*params.VersionId, "id-")
+	}
+	if stage == "" {
+		stage = StageCurrent
+	}
+
+	value, ok := m.secrets[*params.SecretId][stage]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("Secrets Manager can't find the specified secret.")}
+	}
+	return &secretsmanager.GetSecretValueOutput{
+		Name:          params.SecretId,
+		ARN:           aws.String("arn:" + *params.SecretId),
+		VersionId:     aws.String("id-" + stage),
+		VersionStages: []string{stage},
+		SecretString:  aws.String(value),
+	}, nil
+}
+
+func (m *SecretsManagerMock) BatchGetSecretValue(ctx context.Context, params *secretsmanager.BatchGetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.BatchGetSecretValueOutput, error) {
+	m.batches = append(m.batches, params.SecretIdList)
+	output := &secretsmanager.BatchGetSecretValueOutput{}
+	for _, id := range params.SecretIdList {
+		name := strings.TrimPrefix(id, "arn:")
+		value, ok := m.secrets[name][StageCurrent]
+		if !ok {
+			output.Errors = append(output.Errors, types.APIErrorType{SecretId: aws.String(id), ErrorCode: aws.String("ResourceNotFoundException"), Message: aws.String("not found")})
+			continue
+		}
+		output.SecretValues = append(output.SecretValues, types.SecretValueEntry{Name: aws.String(name), ARN: aws.String("arn:" + name), SecretString: aws.String(value)})
+	}
+	return output, nil
+}
+
+const rdsSecret = `{"engine":"postgres","host":"db.internal","port":5432,"username":"app","password":"%s","dbname":"app"}`
+
+func newSecretsManagerMock() *SecretsManagerMock {
+	return &SecretsManagerMock{secrets: map[string]map[string]string{
+		"app/db": {
+			StageCurrent:  fmt.Sprintf(rdsSecret, "current"),
+			StagePrevious: fmt.Sprintf(rdsSecret, "previous"),
+		},
+		"app/api": {StageCurrent: `{"username":"api","password":"key"}`},
+		"app/raw": {StageCurrent: "not json"},
+	}}
+}
+
+func TestGetSecretAs_DecodesVersions(t *testing.T) {
+	source := NewClientSource(newSecretsManagerMock())
+
+	current, err := GetSecretAs[RDSCredentials](context.Background(), source, "app/db")
+	assert.NoError(t, err)
+	assert.Equal(t, RDSCredentials{Engine: "postgres", Host: "db.internal", Port: 5432, Username: "app", Password: "current", DbName: "app"}, *current)
+
+	previous, err := GetSecretVersionAs[RDSCredentials](context.Background(), source, "app/db", WithStage(StagePrevious))
+	assert.NoError(t, err)
+	assert.Equal(t, "previous", previous.Password)
+
+	byId, err := GetSecretVersionAs[RDSCredentials](context.Background(), source, "app/db", WithVersionId("id-"+StagePrevious))
+	assert.NoError(t, err)
+	assert.Equal(t, "previous", byId.Password)
+
+	_, err = GetSecretVersionAs[RDSCredentials](context.Background(), source, "app/db", WithStage(StagePending))
+	var notFound *types.ResourceNotFoundException
+	assert.ErrorAs(t, err, &notFound)
+
+	_, err = GetSecretAs[RDSCredentials](context.Background(), source, "app/raw")
+	assert.ErrorContains(t, err, "app/raw: secret is not a valid JSON awssecretsmanager.RDSCredentials")
+}
+
+func TestBatchGetSecretsAs_ReturnsSecretsAndErrors(t *testing.T) {
+	mock := newSecretsManagerMock()
+	names := []string{"app/db", "arn:app/api", "app/raw", "app/missing"}
+	for i := range 20 {
+		names = append(names, fmt.Sprintf("app/other-%d", i))
+	}
+
+	credentials, err := BatchGetSecretsAs[RDSCredentials](context.Background(), NewClientSource(mock), names)
+
+	assert.Equal(t, 2, len(mock.batches))
+	assert.Equal(t, "current", credentials["app/db"].Password)
+	assert.Equal(t, "key", credentials["arn:app/api"].Password)
+	assert.Equal(t, 2, len(credentials))
+	assert.ErrorContains(t, err, "app/missing: ResourceNotFoundException: not found")
+	assert.ErrorContains(t, err, "app/raw: secret is not a valid JSON")
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestSecretsManagerExtensionClient_IsASecretSource(t *testing.T) {
+	t.Setenv("AWS_SESSION_TOKEN", "token")
+	client := &SecretsManagerExtensionClient{httpClient: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		assert.Equal(t, "token", r.Header.Get("X-Aws-Parameters-Secrets-Token"))
+		query := r.URL.Query()
+		if query.Get("secretId") != "app/db" {
+			return &http.Response{StatusCode: http.StatusBadRequest, Body: io.NopCloser(strings.NewReader("not found"))}, nil
+		}
+		password := "current"
+		if query.Get("versionStage") == StagePending {
+			password = "pending"
+		}
+		body := fmt.Sprintf(`{"Name":"app/db","VersionStages":[%q],"SecretString":%q}`, query.Get("versionStage"), fmt.Sprintf(rdsSecret, password))
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+	})}}
+
+	pending, err := GetSecretVersionAs[RDSCredentials](context.Background(), client, "app/db", WithStage(StagePending))
+	assert.NoError(t, err)
+	assert.Equal(t, "pending", pending.Password)
+
+	credentials, err := BatchGetSecretsAs[RDSCredentials](context.Background(), client, []string{"app/db", "app/missing"})
+	assert.Equal(t, "current", credentials["app/db"].Password)
+	assert.ErrorContains(t, err, "app/missing: HTTP request failed with status code 400")
+}
@@ -0,0 +1,303 @@
+package testing
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/google/uuid"
+)
+
+const (
+	stageCurrent  = "AWSCURRENT"
+	stagePrevious = "AWSPREVIOUS"
+	stagePending  = "AWSPENDING"
+)
+
+type fakeVersion struct {
+	value   *string
+	binary  []byte
+	stages  []string
+	created time.Time
+}
+
+type fakeSecret struct {
+	name            string
+	arn             string
+	rotationEnabled bool
+	versions        map[string]*fakeVersion
+}
+
+// An in-memory Secrets Manager for tests, implementing the operations used by `awssecretsmanager`,
+// including staging labels moving between versions during rotation.
+//
+// Usage:
+// ```go
+//
+//	fake := testing.NewFakeSecretsManager()
+//	fake.CreateSecret(ctx, &secretsmanager.CreateSecretInput{Name: aws.String("my-app/db"), SecretString: aws.String(`{"password":"secret"}`)})
+//	value, err := awssecretsmanager.GetSecret(ctx, fake, "my-app/db")
+//
+// ```
+type FakeSecretsManager struct {
+	mu      sync.Mutex
+	secrets map[string]*fakeSecret
+}
+
+func NewFakeSecretsManager() *FakeSecretsManager {
+	return &FakeSecretsManager{secrets: map[string]*fakeSecret{}}
+}
+
+func (f *FakeSecretsManager) CreateSecret(ctx context.Context, params *secretsmanager.CreateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.CreateSecretOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.Name)
+	if _, exists := f.secrets[name]; exists {
+		return nil, &types.ResourceExistsException{Message: aws.String(fmt.Sprintf("The operation failed because the secret %s already exists.", name))}
+	}
+
+	secret := &fakeSecret{
+		name:     name,
+		arn:      fmt.Sprintf("arn:aws:secretsmanager:eu-north-1:123456789012:secret:%s-%s", name, uuid.NewString()[:6]),
+		versions: map[string]*fakeVersion{},
+	}
+	f.secrets[name] = secret
+
+	output := &secretsmanager.CreateSecretOutput{Name: &secret.name, ARN: &secret.arn}
+	if params.SecretString != nil || params.SecretBinary != nil {
+		id := versionId(params.ClientRequestToken)
+		secret.versions[id] = &fakeVersion{value: params.SecretString, binary: params.SecretBinary, stages: []string{stageCurrent}, created: time.Now()}
+		output.VersionId = &id
+	}
+	return output, nil
+}
+
+// Enables rotation and starts it by adding a version staged as `AWSPENDING` without a value, like Secrets Manager does before invoking the rotation Lambda.
+// Returns the version ID, the `ClientRequestToken` of the rotation steps.
+func (f *FakeSecretsManager) RotateSecret(ctx context.Context, params *secretsmanager.RotateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.RotateSecretOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	secret, err := f.secret(params.SecretId)
+	if err != nil {
+		return nil, err
+	}
+	secret.rotationEnabled = true
+
+	id := versionId(params.ClientRequestToken)
+	removeStage(secret, stagePending)
+	secret.versions[id] = &fakeVersion{stages: []string{stagePending}, created: time.Now()}
+	return &secretsmanager.RotateSecretOutput{Name: &secret.name, ARN: &secret.arn, VersionId: &id}, nil
+}
+
+func (f *FakeSecretsManager) DescribeSecret(ctx context.Context, params *secretsmanager.DescribeSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.DescribeSecretOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	secret, err := f.secret(params.SecretId)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := map[string][]string{}
+	for id, version := range secret.versions {
+		versions[id] = slices.Clone(version.stages)
+	}
+	return &secretsmanager.DescribeSecretOutput{
+		Name:               &secret.name,
+		ARN:                &secret.arn,
+		RotationEnabled:    aws.Bool(secret.rotationEnabled),
+		VersionIdsToStages: versions,
+	}, nil
+}
+
+func (f *FakeSecretsManager) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	secret, err := f.secret(params.SecretId)
+	if err != nil {
+		return nil, err
+	}
+
+	stage := aws.ToString(params.VersionStage)
+	if stage == "" && params.VersionId == nil {
+		stage = stageCurrent
+	}
+	for id, version := range secret.versions {
+		if params.VersionId != nil && *params.VersionId != id {
+			continue
+		}
+		if stage != "" && !slices.Contains(version.stages, stage) {
+			continue
+		}
+		if version.value == nil && version.binary == nil {
+			break
+		}
+		return &secretsmanager.GetSecretValueOutput{
+			Name:          &secret.name,
+			ARN:           &secret.arn,
+			VersionId:     aws.String(id),
+			VersionStages: slices.Clone(version.stages),
+			SecretString:  version.value,
+			SecretBinary:  version.binary,
+			CreatedDate:   aws.Time(version.created),
+		}, nil
+	}
+	return nil, &types.ResourceNotFoundException{Message: aws.String("Secrets Manager can't find the specified secret value for staging label: " + stage)}
+}
+
+func (f *FakeSecretsManager) BatchGetSecretValue(ctx context.Context, params *secretsmanager.BatchGetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.BatchGetSecretValueOutput, error) {
+	output := &secretsmanager.BatchGetSecretValueOutput{}
+	for _, id := range params.SecretIdList {
+		value, err := f.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(id)})
+		if err != nil {
+			output.Errors = append(output.Errors, types.APIErrorType{SecretId: aws.String(id), ErrorCode: aws.String("ResourceNotFoundException"), Message: aws.String(err.Error())})
+			continue
+		}
+		output.SecretValues = append(output.SecretValues, types.SecretValueEntry{
+			Name:          value.Name,
+			ARN:           value.ARN,
+			VersionId:     value.VersionId,
+			VersionStages: value.VersionStages,
+			SecretString:  value.SecretString,
+			SecretBinary:  value.SecretBinary,
+			CreatedDate:   value.CreatedDate,
+		})
+	}
+	return output, nil
+}
+
+func (f *FakeSecretsManager) PutSecretValue(ctx context.Context, params *secretsmanager.PutSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.PutSecretValueOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	secret, err := f.secret(params.SecretId)
+	if err != nil {
+		return nil, err
+	}
+
+	id := versionId(params.ClientRequestToken)
+	stages := params.VersionStages
+	if len(stages) == 0 {
+		stages = []string{stageCurrent}
+	}
+
+	version, exists := secret.versions[id]
+	if exists && (version.value != nil || version.binary != nil) {
+		if aws.ToString(version.value) != aws.ToString(params.SecretString) || string(version.binary) != string(params.SecretBinary) {
+			return nil, &types.ResourceExistsException{Message: aws.String("You can't modify an existing version, you can only create a new version.")}
+		}
+		return &secretsmanager.PutSecretValueOutput{Name: &secret.name, ARN: &secret.arn, VersionId: &id, VersionStages: slices.Clone(version.stages)}, nil
+	}
+	if !exists {
+		version = &fakeVersion{}
+		secret.versions[id] = version
+	}
+	version.value, version.binary, version.created = params.SecretString, params.SecretBinary, time.Now()
+	for _, stage := range stages {
+		moveStage(secret, stage, id)
+	}
+	return &secretsmanager.PutSecretValueOutput{Name: &secret.name, ARN: &secret.arn, VersionId: &id, VersionStages: slices.Clone(version.stages)}, nil
+}
+
+func (f *FakeSecretsManager) UpdateSecretVersionStage(ctx context.Context, params *secretsmanager.UpdateSecretVersionStageInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.UpdateSecretVersionStageOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	secret, err := f.secret(params.SecretId)
+	if err != nil {
+		return nil, err
+	}
+
+	stage := aws.ToString(params.VersionStage)
+	for id, version := range secret.versions {
+		if slices.Contains(version.stages, stage) && id != aws.ToString(params.RemoveFromVersionId) && id != aws.ToString(params.MoveToVersionId) {
+			return nil, &types.InvalidParameterException{Message: aws.String(fmt.Sprintf("The staging label %s is currently attached to version %s, specify it in RemoveFromVersionId.", stage, id))}
+		}
+	}
+
+	if params.MoveToVersionId == nil {
+		if version, ok := secret.versions[aws.ToString(params.RemoveFromVersionId)]; ok {
+			version.stages = slices.DeleteFunc(version.stages, func(s string) bool { return s == stage })
+		}
+	} else {
+		if _, ok := secret.versions[*params.MoveToVersionId]; !ok {
+			return nil, &types.ResourceNotFoundException{Message: aws.String("Secrets Manager can't find the specified secret version.")}
+		}
+		moveStage(secret, stage, *params.MoveToVersionId)
+	}
+	return &secretsmanager.UpdateSecretVersionStageOutput{Name: &secret.name, ARN: &secret.arn}, nil
+}
+
+func (f *FakeSecretsManager) GetRandomPassword(ctx context.Context, params *secretsmanager.GetRandomPasswordInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetRandomPasswordOutput, error) {
+	const characters = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789!#$%&()*+,-.:;<=>?@[]^_{|}~/\"'\\"
+	allowed := []rune(strings.Map(func(r rune) rune {
+		if strings.ContainsRune(aws.ToString(params.ExcludeCharacters), r) {
+			return -1
+		}
+		return r
+	}, characters))
+
+	length := aws.ToInt64(params.PasswordLength)
+	if length == 0 {
+		length = 32
+	}
+	password := make([]rune, length)
+	for i := range password {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(allowed))))
+		if err != nil {
+			return nil, err
+		}
+		password[i] = allowed[n.Int64()]
+	}
+	return &secretsmanager.GetRandomPasswordOutput{RandomPassword: aws.String(string(password))}, nil
+}
+
+func (f *FakeSecretsManager) secret(id *string) (*fakeSecret, error) {
+	for _, secret := range f.secrets {
+		if secret.name == aws.ToString(id) || secret.arn == aws.ToString(id) {
+			return secret, nil
+		}
+	}
+	return nil, &types.ResourceNotFoundException{Message: aws.String("Secrets Manager can't find the specified secret.")}
+}
+
+// Moves `stage` to the version `id`. Moving `AWSCURRENT` moves `AWSPREVIOUS` to the version that was current.
+func moveStage(secret *fakeSecret, stage, id string) {
+	var previous string
+	for versionId, version := range secret.versions {
+		if versionId != id && slices.Contains(version.stages, stage) {
+			previous = versionId
+		}
+	}
+	removeStage(secret, stage)
+	secret.versions[id].stages = append(secret.versions[id].stages, stage)
+
+	if stage == stageCurrent && previous != "" {
+		removeStage(secret, stagePrevious)
+		secret.versions[previous].stages = append(secret.versions[previous].stages, stagePrevious)
+	}
+}
+
+func removeStage(secret *fakeSecret, stage string) {
+	for _, version := range secret.versions {
+		version.stages = slices.DeleteFunc(version.stages, func(s string) bool { return s == stage })
+	}
+}
+
+func versionId(token *string) string {
+	if token != nil {
+		return *token
+	}
+	return uuid.NewString()
+}
@@ -0,0 +1,96 @@
+// Package blobstore stores objects by key in S3, a local directory or memory behind the same interface,
+// so code using S3 can run locally and in unit tests.
+package blobstore
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"io"
+	"iter"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Info about a stored blob. `List` only sets `Key`, `Size`, `ETag` and `LastModified`.
+//
+// The `ETag` is opaque: it changes when the content changes, but is only comparable between blobs of the same store,
+// since S3 gives blobs larger than the upload part size a multipart ETag instead of the MD5 `Memory` and `Filesystem` use.
+type Info struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+	Metadata     map[string]string
+}
+
+// A store of blobs by key, with keys separated by "/" like S3.
+//
+// Usage:
+// ```go
+//
+//	var store blobstore.Blob = blobstore.NewS3(awss3.NewClient(true), presigner, "my-bucket")
+//	if os.Getenv("ENVIRONMENT") == "local" {
+//		store = blobstore.NewFilesystem("./data")
+//	}
+//	err := store.Put(ctx, "reports/2024.csv", reader, blobstore.WithContentType("text/csv"))
+//
+// ```
+type Blob interface {
+	// Returns `ErrNotFound` if the blob doesn't exist. The reader must be closed.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Replaces the blob if it exists.
+	Put(ctx context.Context, key string, body io.Reader, options ...PutOption) error
+	// Deleting a blob that doesn't exist is not an error.
+	Delete(ctx context.Context, key string) error
+	// Lists the blobs with keys starting with `prefix` in lexicographical order.
+	List(ctx context.Context, prefix string) iter.Seq2[Info, error]
+	// Returns `ErrNotFound` if the blob doesn't exist.
+	Stat(ctx context.Context, key string) (*Info, error)
+	// Returns a URL to download the blob from until `expiry` has passed.
+	Presign(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+type putOptions struct {
+	contentType string
+	metadata    map[string]string
+}
+
+type PutOption func(*putOptions)
+
+func WithContentType(contentType string) PutOption {
+	return func(o *putOptions) {
+		o.contentType = contentType
+	}
+}
+
+func WithMetadata(metadata map[string]string) PutOption {
+	return func(o *putOptions) {
+		o.metadata = metadata
+	}
+}
+
+func newPutOptions(options []PutOption) putOptions {
+	o := putOptions{}
+	for _, option := range options {
+		option(&o)
+	}
+	return o
+}
+
+// The ETag S3 gives objects uploaded in a single part.
+func etag(sum []byte) string {
+	return `"` + hex.EncodeToString(sum) + `"`
+}
+
+// Reads `body` while hashing it for the ETag.
+func hashingReader(body io.Reader) (io.Reader, func() string) {
+	hash := md5.New()
+	return io.TeeReader(body, hash), func() string { return etag(hash.Sum(nil)) }
+}
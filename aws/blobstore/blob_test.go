@@ -0,0 +1,285 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type s3Object struct {
+	data        []byte
+	contentType *string
+	metadata    map[string]string
+	etag        string
+	modified    time.Time
+}
+
+// An in-memory S3 bucket supporting the operations used by `S3`.
+type S3Mock struct {
+	mu      sync.Mutex
+	objects map[string]s3Object
+	parts   map[string]map[int32][]byte
+}
+
+func NewS3Mock() *S3Mock {
+	return &S3Mock{objects: map[string]s3Object{}, parts: map[string]map[int32][]byte{}}
+}
+
+func (m *S3Mock) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	object, ok := m.objects[*params.Key]
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(object.data)), ContentLength: aws.Int64(int64(len(object.data)))}, nil
+}
+
+func (m *S3Mock) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	data, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[*params.Key] = s3Object{data, params.ContentType, params.Metadata, objectETag(data), time.Now()}
+	return &s3.PutObjectOutput{ETag: aws.String(objectETag(data))}, nil
+}
+
+func (m *S3Mock) CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.parts[*params.Key] = map[int32][]byte{}
+	m.objects[*params.Key+"\x00pending"] = s3Object{contentType: params.ContentType, metadata: params.Metadata}
+	return &s3.CreateMultipartUploadOutput{UploadId: params.Key}, nil
+}
+
+func (m *S3Mock) UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	data, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.parts[*params.Key][*params.PartNumber] = data
+	return &s3.UploadPartOutput{ETag: aws.String(fmt.Sprintf(`"%d"`, *params.PartNumber))}, nil
+}
+
+func (m *S3Mock) CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var data []byte
+	for _, number := range slices.Sorted(maps.Keys(m.parts[*params.Key])) {
+		data = append(data, m.parts[*params.Key][number]...)
+	}
+	pending := m.objects[*params.Key+"\x00pending"]
+	delete(m.objects, *params.Key+"\x00pending")
+	// S3 gives multipart uploads an ETag of the part ETags and the number of parts, not the MD5 of the content
+	etag := fmt.Sprintf(`"%x-%d"`, md5.Sum(data), len(m.parts[*params.Key]))
+	m.objects[*params.Key] = s3Object{data, pending.contentType, pending.metadata, etag, time.Now()}
+	return &s3.CompleteMultipartUploadOutput{ETag: aws.String(etag)}, nil
+}
+
+func (m *S3Mock) AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func (m *S3Mock) DeleteObjects(ctx context.Context, params *s3.DeleteObjectsInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, object := range params.Delete.Objects {
+		delete(m.objects, *object.Key)
+	}
+	return &s3.DeleteObjectsOutput{}, nil
+}
+
+func (m *S3Mock) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	object, ok := m.objects[*params.Key]
+	if !ok {
+		return nil, &types.NotFound{}
+	}
+	return &s3.HeadObjectOutput{
+		ContentLength: aws.Int64(int64(len(object.data))),
+		ContentType:   object.contentType,
+		ETag:          aws.String(object.etag),
+		LastModified:  aws.Time(object.modified),
+		Metadata:      object.metadata,
+	}, nil
+}
+
+func (m *S3Mock) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	output := &s3.ListObjectsV2Output{}
+	for _, key := range slices.Sorted(maps.Keys(m.objects)) {
+		if !strings.HasPrefix(key, aws.ToString(params.Prefix)) || key <= aws.ToString(params.ContinuationToken) || strings.HasSuffix(key, "\x00pending") {
+			continue
+		}
+		// Two keys per page to exercise pagination
+		if len(output.Contents) == 2 {
+			output.IsTruncated = aws.Bool(true)
+			output.NextContinuationToken = output.Contents[1].Key
+			break
+		}
+		object := m.objects[key]
+		output.Contents = append(output.Contents, types.Object{Key: aws.String(key), Size: aws.Int64(int64(len(object.data))), ETag: aws.String(object.etag), LastModified: aws.Time(object.modified)})
+	}
+	return output, nil
+}
+
+func objectETag(data []byte) string {
+	sum := md5.Sum(data)
+	return etag(sum[:])
+}
+
+func stores(t *testing.T) map[string]Blob {
+	client := s3.New(s3.Options{Region: "eu-north-1", Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", "")})
+	return map[string]Blob{
+		"Memory":     NewMemory(),
+		"Filesystem": NewFilesystem(t.TempDir()),
+		"S3":         NewS3(NewS3Mock(), s3.NewPresignClient(client), "bucket"),
+	}
+}
+
+func read(t *testing.T, store Blob, key string) string {
+	body, err := store.Get(context.Background(), key)
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func keys(t *testing.T, store Blob, prefix string) []string {
+	var list []string
+	for info, err := range store.List(context.Background(), prefix) {
+		assert.NoError(t, err)
+		list = append(list, info.Key)
+	}
+	return list
+}
+
+func TestBlob_Conformance(t *testing.T) {
+	ctx := context.Background()
+
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			t.Run("PutThenGetAndStat", func(t *testing.T) {
+				err := store.Put(ctx, "docs/report.csv", strings.NewReader("a,b\n1,2\n"), WithContentType("text/csv"), WithMetadata(map[string]string{"owner": "team"}))
+				assert.NoError(t, err)
+
+				assert.Equal(t, "a,b\n1,2\n", read(t, store, "docs/report.csv"))
+				info, err := store.Stat(ctx, "docs/report.csv")
+				assert.NoError(t, err)
+				assert.Equal(t, "docs/report.csv", info.Key)
+				assert.Equal(t, int64(8), info.Size)
+				assert.Equal(t, "text/csv", info.ContentType)
+				assert.Equal(t, map[string]string{"owner": "team"}, info.Metadata)
+				assert.Equal(t, objectETag([]byte("a,b\n1,2\n")), info.ETag)
+				assert.WithinDuration(t, time.Now(), info.LastModified, time.Minute)
+			})
+
+			t.Run("PutReplaces", func(t *testing.T) {
+				assert.NoError(t, store.Put(ctx, "replaced.txt", strings.NewReader("first"), WithContentType("text/plain")))
+				assert.NoError(t, store.Put(ctx, "replaced.txt", strings.NewReader("second")))
+
+				assert.Equal(t, "second", read(t, store, "replaced.txt"))
+				info, err := store.Stat(ctx, "replaced.txt")
+				assert.NoError(t, err)
+				assert.Empty(t, info.Metadata)
+			})
+
+			t.Run("PutsLargeBlobs", func(t *testing.T) {
+				data := bytes.Repeat([]byte("0123456789abcdef"), 1024*1024)
+				assert.NoError(t, store.Put(ctx, "large.bin", bytes.NewReader(data)))
+
+				assert.Equal(t, string(data), read(t, store, "large.bin"))
+				info, err := store.Stat(ctx, "large.bin")
+				assert.NoError(t, err)
+				for listed, err := range store.List(ctx, "large.bin") {
+					assert.NoError(t, err)
+					assert.Equal(t, info.ETag, listed.ETag)
+				}
+
+				data[0] = 'x'
+				assert.NoError(t, store.Put(ctx, "large.bin", bytes.NewReader(data)))
+				replaced, err := store.Stat(ctx, "large.bin")
+				assert.NoError(t, err)
+				assert.NotEqual(t, info.ETag, replaced.ETag)
+			})
+
+			t.Run("MissingBlobs", func(t *testing.T) {
+				_, err := store.Get(ctx, "missing.txt")
+				assert.ErrorIs(t, err, ErrNotFound)
+				_, err = store.Stat(ctx, "missing.txt")
+				assert.ErrorIs(t, err, ErrNotFound)
+				assert.NoError(t, store.Delete(ctx, "missing.txt"))
+			})
+
+			t.Run("Delete", func(t *testing.T) {
+				assert.NoError(t, store.Put(ctx, "deleted.txt", strings.NewReader("data")))
+				assert.NoError(t, store.Delete(ctx, "deleted.txt"))
+
+				_, err := store.Get(ctx, "deleted.txt")
+				assert.ErrorIs(t, err, ErrNotFound)
+				assert.NotContains(t, keys(t, store, ""), "deleted.txt")
+			})
+
+			t.Run("ListInLexicographicalOrder", func(t *testing.T) {
+				for _, key := range []string{"list/b/2.txt", "list/a-b.txt", "list/a/1.txt", "list/c.txt", "listing.txt"} {
+					assert.NoError(t, store.Put(ctx, key, strings.NewReader(key)))
+				}
+
+				assert.Equal(t, []string{"list/a-b.txt", "list/a/1.txt", "list/b/2.txt", "list/c.txt"}, keys(t, store, "list/"))
+				assert.Equal(t, []string{"list/a-b.txt", "list/a/1.txt"}, keys(t, store, "list/a"))
+				assert.Empty(t, keys(t, store, "nothing/"))
+
+				for info, err := range store.List(ctx, "list/c") {
+					assert.NoError(t, err)
+					assert.Equal(t, Info{Key: "list/c.txt", Size: 10, ETag: objectETag([]byte("list/c.txt")), LastModified: info.LastModified}, info)
+				}
+			})
+
+			t.Run("ListStopsWhenBreaking", func(t *testing.T) {
+				count := 0
+				for range store.List(ctx, "list/") {
+					count++
+					break
+				}
+				assert.Equal(t, 1, count)
+			})
+
+			t.Run("Presign", func(t *testing.T) {
+				url, err := store.Presign(ctx, "docs/report.csv", time.Minute)
+				assert.NoError(t, err)
+				assert.Contains(t, url, "docs/report.csv")
+			})
+		})
+	}
+}
+
+func TestFilesystem_RejectsKeysOutsideRoot(t *testing.T) {
+	store := NewFilesystem(t.TempDir())
+
+	for _, key := range []string{"", "../escape.txt", "/absolute.txt", "a//b", ".blobstore/attributes/x.json"} {
+		assert.ErrorIs(t, store.Put(context.Background(), key, strings.NewReader("data")), ErrInvalidKey, key)
+	}
+}
@@ -0,0 +1,231 @@
+package blobstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"iter"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Directory below the root keeping the content type, metadata and ETag of the blobs, and files being written.
+const attributesDir = ".blobstore"
+
+type attributes struct {
+	ContentType string            `json:"contentType,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	ETag        string            `json:"etag"`
+}
+
+// Stores blobs as files below a directory, for local development.
+// Keys map to paths, so a key can't be both a blob and a prefix of other blobs followed by "/", like "a" and "a/b".
+type Filesystem struct {
+	root string
+}
+
+var _ Blob = (*Filesystem)(nil)
+
+func NewFilesystem(root string) *Filesystem {
+	return &Filesystem{root}
+}
+
+func (f *Filesystem) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := f.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stat, err := file.Stat(); err != nil || stat.IsDir() {
+		file.Close()
+		return nil, ErrNotFound
+	}
+	return file, nil
+}
+
+// Writes to a temporary file first, so readers never see a partially written blob.
+func (f *Filesystem) Put(ctx context.Context, key string, body io.Reader, options ...PutOption) error {
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	o := newPutOptions(options)
+
+	tmpDir := filepath.Join(f.root, attributesDir, "tmp")
+	if err := os.MkdirAll(tmpDir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(tmpDir, "blob-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	reader, sum := hashingReader(body)
+	if _, err := io.Copy(tmp, reader); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(attributes{ContentType: o.contentType, Metadata: o.metadata, ETag: sum()})
+	if err != nil {
+		return err
+	}
+	attributesPath := f.attributesPath(key)
+	if err := os.MkdirAll(filepath.Dir(attributesPath), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(attributesPath, data, 0o644); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (f *Filesystem) Delete(ctx context.Context, key string) error {
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := os.Remove(f.attributesPath(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (f *Filesystem) List(ctx context.Context, prefix string) iter.Seq2[Info, error] {
+	return func(yield func(Info, error) bool) {
+		// Only walk the directory of the prefix
+		start := f.root
+		if i := strings.LastIndex(prefix, "/"); i >= 0 {
+			start = filepath.Join(f.root, filepath.FromSlash(prefix[:i]))
+		}
+
+		var keys []string
+		err := filepath.WalkDir(start, func(path string, entry fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) && path == start {
+				return filepath.SkipAll
+			}
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() && path == filepath.Join(f.root, attributesDir) {
+				return filepath.SkipDir
+			}
+			if entry.IsDir() {
+				return nil
+			}
+
+			relative, err := filepath.Rel(f.root, path)
+			if err != nil {
+				return err
+			}
+			if key := filepath.ToSlash(relative); strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+			return nil
+		})
+		if err != nil {
+			yield(Info{}, err)
+			return
+		}
+
+		// Walking orders "a/b" before "a-b", unlike S3
+		slices.Sort(keys)
+		for _, key := range keys {
+			info, err := f.Stat(ctx, key)
+			if errors.Is(err, ErrNotFound) {
+				// Deleted while listing
+				continue
+			}
+			if err != nil {
+				yield(Info{}, err)
+				return
+			}
+			if !yield(Info{Key: info.Key, Size: info.Size, ETag: info.ETag, LastModified: info.LastModified}, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (f *Filesystem) Stat(ctx context.Context, key string) (*Info, error) {
+	path, err := f.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && stat.IsDir()) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var attrs attributes
+	data, err := os.ReadFile(f.attributesPath(key))
+	if err == nil {
+		err = json.Unmarshal(data, &attrs)
+	}
+	// Files copied into the directory have no attributes
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	return &Info{
+		Key:          key,
+		Size:         stat.Size(),
+		ContentType:  attrs.ContentType,
+		ETag:         attrs.ETag,
+		LastModified: stat.ModTime(),
+		Metadata:     attrs.Metadata,
+	}, nil
+}
+
+// Returns a `file://` URL of the blob. Doesn't expire.
+func (f *Filesystem) Presign(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	path, err := f.path(key)
+	if err != nil {
+		return "", err
+	}
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(absolute)}
+	return u.String(), nil
+}
+
+func (f *Filesystem) path(key string) (string, error) {
+	if !fs.ValidPath(key) || key == "." || key == attributesDir || strings.HasPrefix(key, attributesDir+"/") {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(f.root, filepath.FromSlash(key)), nil
+}
+
+func (f *Filesystem) attributesPath(key string) string {
+	return filepath.Join(f.root, attributesDir, "attributes", filepath.FromSlash(key)+".json")
+}
@@ -0,0 +1,114 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"iter"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type memoryBlob struct {
+	data []byte
+	info Info
+}
+
+// Keeps blobs in memory, for unit tests.
+type Memory struct {
+	mu    sync.RWMutex
+	blobs map[string]memoryBlob
+}
+
+var _ Blob = (*Memory)(nil)
+
+func NewMemory() *Memory {
+	return &Memory{blobs: map[string]memoryBlob{}}
+}
+
+func (m *Memory) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	blob, ok := m.blobs[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(blob.data)), nil
+}
+
+func (m *Memory) Put(ctx context.Context, key string, body io.Reader, options ...PutOption) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+	o := newPutOptions(options)
+
+	reader, sum := hashingReader(body)
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blobs[key] = memoryBlob{data, Info{
+		Key:          key,
+		Size:         int64(len(data)),
+		ContentType:  o.contentType,
+		ETag:         sum(),
+		LastModified: time.Now(),
+		Metadata:     maps.Clone(o.metadata),
+	}}
+	return nil
+}
+
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.blobs, key)
+	return nil
+}
+
+func (m *Memory) List(ctx context.Context, prefix string) iter.Seq2[Info, error] {
+	return func(yield func(Info, error) bool) {
+		m.mu.RLock()
+		var infos []Info
+		for key, blob := range m.blobs {
+			if strings.HasPrefix(key, prefix) {
+				infos = append(infos, Info{Key: key, Size: blob.info.Size, ETag: blob.info.ETag, LastModified: blob.info.LastModified})
+			}
+		}
+		m.mu.RUnlock()
+
+		slices.SortFunc(infos, func(a, b Info) int { return strings.Compare(a.Key, b.Key) })
+		for _, info := range infos {
+			if !yield(info, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (m *Memory) Stat(ctx context.Context, key string) (*Info, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	blob, ok := m.blobs[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	info := blob.info
+	info.Metadata = maps.Clone(info.Metadata)
+	return &info, nil
+}
+
+// Returns a `memory://` URL, which can't be downloaded from.
+func (m *Memory) Presign(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	u := url.URL{Scheme: "memory", Path: "/" + key, RawQuery: url.Values{"expires": {time.Now().Add(expiry).UTC().Format(time.RFC3339)}}.Encode()}
+	return u.String(), nil
+}
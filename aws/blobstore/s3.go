@@ -0,0 +1,109 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/oslokommune/common-lib-go/aws/awss3"
+)
+
+// The S3 operations used by `S3`, implemented by `*s3.Client`.
+type S3API interface {
+	awss3.GetObjectAPI
+	awss3.UploadAPI
+	awss3.DeleteObjectsAPI
+	awss3.HeadObjectAPI
+	awss3.ListObjectsV2API
+}
+
+// Stores blobs as objects in an S3 bucket.
+//
+// Usage:
+// ```go
+//
+//	client := awss3.NewClient(true)
+//	store := blobstore.NewS3(client, s3.NewPresignClient(client), "my-bucket")
+//
+// ```
+type S3 struct {
+	client    S3API
+	presigner awss3.PresignObject
+	bucket    string
+}
+
+var _ Blob = (*S3)(nil)
+
+func NewS3(client S3API, presigner awss3.PresignObject, bucket string) *S3 {
+	return &S3{client, presigner, bucket}
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	body, err := awss3.DownloadFileStream(ctx, s.client, s.bucket, key)
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return nil, ErrNotFound
+	}
+	return body, err
+}
+
+func (s *S3) Put(ctx context.Context, key string, body io.Reader, options ...PutOption) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+	o := newPutOptions(options)
+
+	var objectOptions []awss3.ObjectOption
+	if o.contentType != "" {
+		objectOptions = append(objectOptions, awss3.WithContentType(o.contentType))
+	}
+	if o.metadata != nil {
+		objectOptions = append(objectOptions, awss3.WithMetadata(o.metadata))
+	}
+	_, err := awss3.UploadStream(ctx, s.client, s.bucket, key, body, objectOptions...)
+	return err
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	return awss3.DeleteObjects(ctx, s.client, s.bucket, []string{key})
+}
+
+func (s *S3) List(ctx context.Context, prefix string) iter.Seq2[Info, error] {
+	return func(yield func(Info, error) bool) {
+		for file, err := range awss3.ListObjects(ctx, s.client, s.bucket, prefix) {
+			if err != nil {
+				yield(Info{}, err)
+				return
+			}
+			if !yield(Info{Key: file.Name, Size: file.Size, ETag: file.ETag, LastModified: file.CreatedAt}, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (s *S3) Stat(ctx context.Context, key string) (*Info, error) {
+	info, err := awss3.HeadObject(ctx, s.client, s.bucket, key)
+	if errors.Is(err, awss3.ErrObjectNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Info{
+		Key:          key,
+		Size:         info.Size,
+		ContentType:  info.ContentType,
+		ETag:         info.ETag,
+		LastModified: info.LastModified,
+		Metadata:     info.Metadata,
+	}, nil
+}
+
+func (s *S3) Presign(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return awss3.GeneratePresignedInlineURL(ctx, s.presigner, s.bucket, key, "", "", expiry)
+}
@@ -0,0 +1,64 @@
+package configurationreader
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/oslokommune/common-lib-go/aws/internal/decode"
+)
+
+// A configuration value in the config struct.
+type Field struct {
+	// Path of `json` tag names, or field names, separated by dots, e.g. `db.host` for the `host` field in the `db` struct.
+	Key string
+	// Tags of the struct field
+	Tag  reflect.StructTag
+	Type reflect.Type
+
+	value reflect.Value
+	// Name of the field's parameter below the path of `FromParameterPath`, like in `awsssm.GetParameterStoreHierarchy`
+	parameter string
+}
+
+// Names of environment variables read for the field, in order of priority.
+// The `env` tag if set, otherwise the key as is and in upper snake case, e.g. `db.maxConnections` and `DB_MAX_CONNECTIONS`.
+func (f Field) EnvNames() []string {
+	if env := f.Tag.Get("env"); env != "" {
+		return []string{env}
+	}
+	return []string{f.Key, envName(f.Key)}
+}
+
+// Collects the fields of the struct `v` points to, recursing into nested structs.
+func fields(v reflect.Value) []Field {
+	var out []Field
+	for _, field := range decode.Fields(v.Elem(), "json") {
+		out = append(out, Field{
+			Key:       field.Key(".", "json"),
+			Tag:       field.Tag(),
+			Type:      field.Type(),
+			value:     field.Value,
+			parameter: field.Key("/", "ssm", "json"),
+		})
+	}
+	return out
+}
+
+// Converts a key like `db.maxConnections` to `DB_MAX_CONNECTIONS`.
+func envName(key string) string {
+	var b strings.Builder
+	runes := []rune(key)
+	for i, r := range runes {
+		switch {
+		case r == '.' || r == '-':
+			b.WriteRune('_')
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])):
+			b.WriteRune('_')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,196 @@
+package configurationreader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/oslokommune/common-lib-go/aws/internal/decode"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type loader struct {
+	requireAll       bool
+	logConfiguration bool
+}
+
+type Option func(*loader)
+
+// Requires all fields except bools to have a non-zero value, like `ReadConfiguration` always has.
+func RequireAll() Option {
+	return func(l *loader) {
+		l.requireAll = true
+	}
+}
+
+// Logs the effective configuration, and the source of each value, when it's loaded.
+// Secrets are masked, see `Load`.
+func LogConfiguration() Option {
+	return func(l *loader) {
+		l.logConfiguration = true
+	}
+}
+
+type sourceValues struct {
+	name string
+	// Values keyed by lower case keys, since keys are matched ignoring case like `encoding/json`
+	values Values
+	keys   map[string]string
+}
+
+// Reads configuration into a new `T` from `sources`, where values from later sources override earlier ones.
+// Fields are matched by their `Field.Key`, ignoring case.
+//
+// Supported field types are strings, bools, integers, floats, `time.Duration`, slices, maps, pointers, nested structs,
+// and types implementing `encoding.TextUnmarshaler` or `json.Unmarshaler` such as `time.Time` and `localtime.Date`.
+//
+// Values are validated with the `required`, `min`, `max`, `oneof` and `pattern` tags, see `validate`.
+// Fields tagged `secret:"true"`, or named like one of `logging.DefaultRedactedFields`, are masked in errors and logs.
+//
+// All errors, from sources, decoding and validating values, are returned together.
+//
+// Usage:
+// ```go
+//
+//	cfg, err := configurationreader.Load[Config](ctx, []configurationreader.Source{
+//		configurationreader.FromDefaults(),
+//		configurationreader.Optional(configurationreader.FromFile(".env")),
+//		configurationreader.FromParameterPath(awsssm.NewClient(true), "/my-app/prod"),
+//		configurationreader.FromSecret(awssecretsmanager.NewClient(true), "my-app/prod/db"),
+//		configurationreader.FromEnv(),
+//	}, configurationreader.LogConfiguration())
+//
+// ```
+func Load[T any](ctx context.Context, sources []Source, options ...Option) (*T, error) {
+	cfg, _, err := load[T](ctx, sources, options...)
+	return cfg, err
+}
+
+// Same as `Load`, also returning the name of the source of each field's value.
+func load[T any](ctx context.Context, sources []Source, options ...Option) (*T, map[string]string, error) {
+	l := &loader{}
+	for _, option := range options {
+		option(l)
+	}
+
+	var cfg T
+	v := reflect.ValueOf(&cfg)
+	if v.Elem().Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("configuration must be a struct, got %T", cfg)
+	}
+	fs := fields(v)
+
+	var errs []error
+	loaded := make([]sourceValues, 0, len(sources))
+	for _, source := range sources {
+		values, err := source.Load(ctx, fs)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("reading configuration from %s: %w", source.Name(), err))
+			continue
+		}
+
+		s := sourceValues{source.Name(), Values{}, map[string]string{}}
+		for key, value := range values {
+			s.values[strings.ToLower(key)] = value
+			s.keys[strings.ToLower(key)] = key
+		}
+		loaded = append(loaded, s)
+	}
+
+	origins := map[string]string{}
+	for _, field := range fs {
+		origin, err := l.setField(field, loaded)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if origin != "" {
+			origins[field.Key] = origin
+		}
+	}
+
+	if l.logConfiguration {
+		logConfiguration(fs, origins)
+	}
+
+	if len(errs) > 0 {
+		return &cfg, origins, errors.Join(errs...)
+	}
+	return &cfg, origins, nil
+}
+
+// Sets the field from the last source with a value, and returns the name of that source.
+func (l *loader) setField(field Field, sources []sourceValues) (string, error) {
+	key := strings.ToLower(field.Key)
+
+	if field.Type.Kind() == reflect.Map {
+		return setMapField(field, key, sources)
+	}
+
+	origin, value := "", ""
+	for _, source := range sources {
+		if v, ok := source.values[key]; ok {
+			origin, value = source.name, v
+		}
+	}
+
+	if origin != "" {
+		if err := decode.Value(field.value, value); err != nil {
+			return origin, fmt.Errorf("%s: invalid value from %s: %w", field.Key, origin, err)
+		}
+	}
+
+	if l.requireAll && field.value.IsZero() && field.Type.Kind() != reflect.Bool {
+		return origin, fmt.Errorf("%s: missing value, set it in one of the sources or the environment variable %s", field.Key, field.EnvNames()[0])
+	}
+	return origin, validate(field, origin)
+}
+
+// Maps are merged from all sources, given either as one value or one entry per map key.
+func setMapField(field Field, key string, sources []sourceValues) (string, error) {
+	var origins []string
+	for _, source := range sources {
+		found := false
+		if value, ok := source.values[key]; ok {
+			if err := decode.Value(field.value, value); err != nil {
+				return "", fmt.Errorf("%s: invalid value from %s: %w", field.Key, source.name, err)
+			}
+			found = true
+		}
+
+		entries := map[string]string{}
+		for k, value := range source.values {
+			if strings.HasPrefix(k, key+".") {
+				entries[source.keys[k][len(key)+1:]] = value
+			}
+		}
+		if len(entries) > 0 {
+			if err := decode.Map(field.value, entries); err != nil {
+				return "", fmt.Errorf("%s: invalid value from %s: %w", field.Key, source.name, err)
+			}
+			found = true
+		}
+
+		if found {
+			origins = append(origins, source.name)
+		}
+	}
+	origin := strings.Join(origins, ", ")
+	return origin, validate(field, origin)
+}
+
+// Logs each field's value, masking secrets, and the source it came from.
+func logConfiguration(fs []Field, origins map[string]string) {
+	dict := zerolog.Dict()
+	for _, field := range fs {
+		origin := origins[field.Key]
+		if origin == "" {
+			origin = "unset"
+		}
+		dict.Str(field.Key, displayValue(field)+" ("+origin+")")
+	}
+	log.Info().Dict("configuration", dict).Msg("Effective configuration")
+}
@@ -0,0 +1,170 @@
+package configurationreader
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/oslokommune/common-lib-go/localtime"
+	"github.com/stretchr/testify/assert"
+)
+
+type DatabaseConfig struct {
+	Host           string `json:"host"`
+	Port           int    `json:"port" default:"5432"`
+	MaxConnections int64  `json:"maxConnections"`
+}
+
+type AppConfig struct {
+	Name       string            `json:"name"`
+	Timeout    time.Duration     `json:"timeout" default:"5s"`
+	Ratio      float64           `json:"ratio"`
+	Hosts      []string          `json:"hosts"`
+	Ports      []int             `json:"ports"`
+	Labels     map[string]string `json:"labels"`
+	ValidFrom  localtime.Date    `json:"validFrom"`
+	Debug      *bool             `json:"debug"`
+	Database   DatabaseConfig    `json:"db"`
+	Unexported string
+}
+
+type ParametersByPathMock struct {
+	pages [][]types.Parameter
+}
+
+func (m ParametersByPathMock) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	page := 0
+	if params.NextToken != nil {
+		page = 1
+	}
+	output := &ssm.GetParametersByPathOutput{Parameters: m.pages[page]}
+	if page+1 < len(m.pages) {
+		output.NextToken = aws.String("next")
+	}
+	return output, nil
+}
+
+func parameter(name, value string) types.Parameter {
+	return types.Parameter{Name: aws.String(name), Value: aws.String(value)}
+}
+
+func TestLoad_LayersSourcesAndDecodesTypes(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte(`
+name: from-file
+ratio: 0.5
+hosts: [a.example.com, b.example.com]
+labels:
+  team: bymiljo
+db:
+  host: localhost
+`), 0o644))
+
+	ssmClient := ParametersByPathMock{pages: [][]types.Parameter{
+		{parameter("/app/prod/db/host", "db.internal"), parameter("/app/prod/validFrom", "2024-05-17")},
+		{parameter("/app/prod/labels/env", "prod")},
+	}}
+
+	t.Setenv("DB_MAX_CONNECTIONS", "20")
+	t.Setenv("PORTS", "80, 443")
+	t.Setenv("DEBUG", "true")
+
+	cfg, err := Load[AppConfig](context.Background(), []Source{
+		FromDefaults(),
+		FromFile(file),
+		FromParameterPath(ssmClient, "/app/prod"),
+		FromEnv(),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "from-file", cfg.Name)
+	assert.Equal(t, 5*time.Second, cfg.Timeout)
+	assert.Equal(t, 0.5, cfg.Ratio)
+	assert.Equal(t, []string{"a.example.com", "b.example.com"}, cfg.Hosts)
+	assert.Equal(t, []int{80, 443}, cfg.Ports)
+	assert.Equal(t, map[string]string{"team": "bymiljo", "env": "prod"}, cfg.Labels)
+	assert.Equal(t, "2024-05-17", cfg.ValidFrom.String())
+	assert.Equal(t, true, *cfg.Debug)
+	assert.Equal(t, DatabaseConfig{Host: "db.internal", Port: 5432, MaxConnections: 20}, cfg.Database)
+}
+
+func TestFromParameterPath_NamesParametersLikeHierarchy(t *testing.T) {
+	type Config struct {
+		Database struct {
+			Host string `ssm:"hostname" json:"host"`
+		} `ssm:"database" json:"db"`
+		Features map[string]bool `ssm:"features"`
+		Local    string          `ssm:"-" json:"local"`
+	}
+	ssmClient := ParametersByPathMock{pages: [][]types.Parameter{{
+		parameter("/app/prod/Database/Hostname", "db.internal"),
+		parameter("/app/prod/features/search", "true"),
+		parameter("/app/prod/local", "from-ssm"),
+	}}}
+
+	cfg, err := Load[Config](context.Background(), []Source{FromParameterPath(ssmClient, "/app/prod")})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "db.internal", cfg.Database.Host)
+	assert.Equal(t, map[string]bool{"search": true}, cfg.Features)
+	assert.Empty(t, cfg.Local)
+}
+
+func TestLoad_ReadsDotEnvFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".env")
+	assert.NoError(t, os.WriteFile(file, []byte("# local settings\nNAME=\"local\"\nexport DB_HOST=localhost\n"), 0o644))
+
+	cfg, err := Load[AppConfig](context.Background(), []Source{FromFile(file)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "local", cfg.Name)
+	assert.Equal(t, "localhost", cfg.Database.Host)
+}
+
+func TestLoad_DecodesSlicesOfStructsFromJSON(t *testing.T) {
+	type Route struct {
+		Path    string   `json:"path"`
+		Methods []string `json:"methods"`
+	}
+	type Config struct {
+		Routes   []Route          `json:"routes"`
+		Backends map[string]Route `json:"backends"`
+		Matrix   [][]int          `json:"matrix"`
+	}
+	file := filepath.Join(t.TempDir(), "config.json")
+	assert.NoError(t, os.WriteFile(file, []byte(`{
+		"routes": [{"path": "/a", "methods": ["GET", "POST"]}, {"path": "/b"}],
+		"backends": {"api": {"path": "/api"}},
+		"matrix": [[1, 2], [3]]
+	}`), 0o644))
+
+	cfg, err := Load[Config](context.Background(), []Source{FromFile(file)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Route{{Path: "/a", Methods: []string{"GET", "POST"}}, {Path: "/b"}}, cfg.Routes)
+	assert.Equal(t, [][]int{{1, 2}, {3}}, cfg.Matrix)
+	assert.Equal(t, map[string]Route{"api": {Path: "/api"}}, cfg.Backends)
+}
+
+func TestLoad_ReturnsAllErrors(t *testing.T) {
+	failing := NewSource("failing", func(ctx context.Context, fields []Field) (Values, error) {
+		return nil, errors.New("access denied")
+	})
+	values := NewSource("values", func(ctx context.Context, fields []Field) (Values, error) {
+		return Values{"timeout": "soon", "db.port": "many"}, nil
+	})
+
+	_, err := Load[AppConfig](context.Background(), []Source{failing, Optional(failing), values})
+
+	assert.ErrorContains(t, err, "reading configuration from failing: access denied")
+	assert.ErrorContains(t, err, "timeout: invalid value from values")
+	assert.ErrorContains(t, err, "db.port: invalid value from values")
+	assert.Equal(t, 3, len(err.(interface{ Unwrap() []error }).Unwrap()))
+}
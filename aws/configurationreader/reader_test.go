@@ -136,7 +136,7 @@ func TestReadConfigurationUsesOverrideIfUnauthorized(t *testing.T) {
 	assert.EqualValues(t, expectedConfig, *config)
 }
 
-func TestPanicIfConfigFieldMissing(t *testing.T) {
+func TestErrorIfConfigFieldMissing(t *testing.T) {
 	ctx := context.Background()
 	mock := ParameterStoreClientNoHostMock{}
 
@@ -144,5 +144,7 @@ func TestPanicIfConfigFieldMissing(t *testing.T) {
 	os.Setenv("times", "8080")
 	os.Unsetenv("HOST")
 
-	assert.Panics(t, func() { ReadConfiguration[Config](ctx, mock, "config") })
+	_, err := ReadConfiguration[Config](ctx, mock, "config")
+
+	assert.ErrorContains(t, err, "host: missing value")
 }
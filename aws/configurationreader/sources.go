@@ -0,0 +1,260 @@
+package configurationreader
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	awsssm "github.com/oslokommune/common-lib-go/aws/awsparameterstore"
+	"github.com/oslokommune/common-lib-go/aws/awssecretsmanager"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+// Values of configuration fields keyed by `Field.Key`.
+// Maps may also be given as one entry per map key, e.g. `labels.team` for the key `team` in the `labels` map.
+type Values map[string]string
+
+// Where configuration values are read from, e.g. environment variables or Parameter Store.
+type Source interface {
+	// Describes the source in errors, e.g. `env` or `ssm:/app/prod`
+	Name() string
+	// Reads the values of `fields` available in the source
+	Load(ctx context.Context, fields []Field) (Values, error)
+}
+
+type sourceFunc struct {
+	name string
+	load func(ctx context.Context, fields []Field) (Values, error)
+}
+
+func (s sourceFunc) Name() string {
+	return s.name
+}
+
+func (s sourceFunc) Load(ctx context.Context, fields []Field) (Values, error) {
+	return s.load(ctx, fields)
+}
+
+// Creates a source from a function, e.g. for reading from a custom store.
+func NewSource(name string, load func(ctx context.Context, fields []Field) (Values, error)) Source {
+	return sourceFunc{name, load}
+}
+
+// Values from `default` tags, e.g. `default:"8080"`.
+func FromDefaults() Source {
+	return NewSource("default", func(_ context.Context, fields []Field) (Values, error) {
+		values := Values{}
+		for _, field := range fields {
+			if value, ok := field.Tag.Lookup("default"); ok {
+				values[field.Key] = value
+			}
+		}
+		return values, nil
+	})
+}
+
+// Values from environment variables, see `Field.EnvNames`.
+func FromEnv() Source {
+	return NewSource("env", func(_ context.Context, fields []Field) (Values, error) {
+		return lookupEnv(fields, os.LookupEnv), nil
+	})
+}
+
+// Values from a local file, typically used during development.
+// Files ending with `.yaml`, `.yml` or `.json` are read as documents with the same structure as the config struct,
+// other files as `.env` files with `NAME=value` lines, using the same names as `FromEnv`.
+func FromFile(path string) Source {
+	return NewSource("file:"+path, func(_ context.Context, fields []Field) (Values, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml":
+			var document any
+			if err := yaml.Unmarshal(data, &document); err != nil {
+				return nil, err
+			}
+			return flatten(document, fields), nil
+		case ".json":
+			return parseJSONDocument(data, fields)
+		default:
+			env, err := parseDotEnv(data)
+			if err != nil {
+				return nil, err
+			}
+			return lookupEnv(fields, func(name string) (string, bool) {
+				value, ok := env[name]
+				return value, ok
+			}), nil
+		}
+	})
+}
+
+// Values from a Parameter Store parameter containing a JSON document with the same structure as the config struct.
+func FromParameter(client awsssm.GetParameterAPI, name string) Source {
+	return NewSource("ssm:"+name, func(ctx context.Context, fields []Field) (Values, error) {
+		value, err := awsssm.GetParameterStoreParameterString(ctx, client, name)
+		if err != nil {
+			return nil, err
+		}
+		return parseJSONDocument([]byte(*value), fields)
+	})
+}
+
+// Values from the Parameter Store parameters below `path`, one parameter per field,
+// e.g. `/app/prod/db/host` for the field `db.host` when `path` is `/app/prod`.
+// Parameters are named like in `awsssm.GetParameterStoreHierarchy`, by the `ssm` tag, `json` tag or field name, ignoring case.
+func FromParameterPath(client awsssm.GetParametersByPathApi, path string) Source {
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	return NewSource("ssm:"+path, func(ctx context.Context, fields []Field) (Values, error) {
+		parameters, err := awsssm.GetParameterStoreParametersByPath(ctx, client, prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		names := make(map[string]string, len(parameters))
+		for name := range parameters {
+			names[strings.ToLower(strings.TrimPrefix(name, prefix))] = name
+		}
+
+		values := Values{}
+		for _, field := range fields {
+			if field.parameter == "" {
+				continue
+			}
+			key := strings.ToLower(field.parameter)
+			if name, ok := names[key]; ok {
+				values[field.Key] = parameters[name]
+			}
+			if field.Type.Kind() == reflect.Map {
+				for relative, name := range names {
+					if strings.HasPrefix(relative, key+"/") {
+						values[field.Key+"."+name[len(prefix)+len(key)+1:]] = parameters[name]
+					}
+				}
+			}
+		}
+		return values, nil
+	})
+}
+
+// Values from a Secrets Manager secret containing a JSON document with the same structure as the config struct.
+func FromSecret(client awssecretsmanager.GetSecretValueApi, name string) Source {
+	return NewSource("secretsmanager:"+name, func(ctx context.Context, fields []Field) (Values, error) {
+		secret, err := awssecretsmanager.GetSecret(ctx, client, name)
+		if err != nil {
+			return nil, err
+		}
+		return parseJSONDocument([]byte(*secret), fields)
+	})
+}
+
+// Ignores errors from `source`, e.g. a missing local file or missing permissions to read from Parameter Store, after logging them.
+func Optional(source Source) Source {
+	return NewSource(source.Name(), func(ctx context.Context, fields []Field) (Values, error) {
+		values, err := source.Load(ctx, fields)
+		if err != nil {
+			log.Info().Err(err).Msgf("Skipping optional configuration source %s", source.Name())
+			return Values{}, nil
+		}
+		return values, nil
+	})
+}
+
+func lookupEnv(fields []Field, lookup func(name string) (string, bool)) Values {
+	values := Values{}
+	for _, field := range fields {
+		for _, name := range field.EnvNames() {
+			if value, ok := lookup(name); ok {
+				values[field.Key] = value
+				break
+			}
+		}
+	}
+	return values
+}
+
+func parseJSONDocument(data []byte, fields []Field) (Values, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	if _, ok := document.(map[string]any); !ok {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+	return flatten(document, fields), nil
+}
+
+// Flattens nested objects into keys separated by dots. Arrays, and objects of map fields, are kept as JSON,
+// so they can hold structs.
+func flatten(document any, fields []Field) Values {
+	maps := map[string]bool{}
+	for _, field := range fields {
+		if field.Type.Kind() == reflect.Map {
+			maps[strings.ToLower(field.Key)] = true
+		}
+	}
+
+	values := Values{}
+	var walk func(prefix string, value any)
+	walk = func(prefix string, value any) {
+		switch v := value.(type) {
+		case map[string]any:
+			if maps[strings.ToLower(prefix)] {
+				data, _ := json.Marshal(v)
+				values[prefix] = string(data)
+				return
+			}
+			for key, child := range v {
+				if prefix != "" {
+					key = prefix + "." + key
+				}
+				walk(key, child)
+			}
+		case nil:
+		case string:
+			values[prefix] = v
+		case []any:
+			data, _ := json.Marshal(v)
+			values[prefix] = string(data)
+		default:
+			values[prefix] = fmt.Sprint(v)
+		}
+	}
+	walk("", document)
+	return values
+}
+
+func parseDotEnv(data []byte) (map[string]string, error) {
+	env := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, value, found := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !found {
+			return nil, fmt.Errorf("line %d: expected NAME=value", n)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		env[strings.TrimSpace(name)] = value
+	}
+	return env, scanner.Err()
+}
@@ -0,0 +1,166 @@
+package configurationreader
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oslokommune/common-lib-go/aws/internal/decode"
+	"github.com/oslokommune/common-lib-go/logging"
+)
+
+// Checks the constraints in the tags of `field` against its value:
+//
+//   - `required:"true"` requires a value from one of the sources, so zero values like `0` and `""` are valid when given explicitly
+//   - `min:"1"` and `max:"10"` limit numbers and durations, and the length of strings, slices and maps
+//   - `oneof:"debug info warn"` limits the value to a space separated list of values
+//   - `pattern:"^[a-z-]+$"` requires strings to match a regular expression
+//
+// Constraints other than `required` are only checked for fields with a value.
+func validate(field Field, origin string) error {
+	if origin == "" {
+		if required, _ := strconv.ParseBool(field.Tag.Get("required")); required {
+			return fmt.Errorf("%s: missing value, set it in one of the sources or the environment variable %s", field.Key, field.EnvNames()[0])
+		}
+		return nil
+	}
+
+	v := field.value
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%s: value %s from %s %s", field.Key, displayValue(field), origin, fmt.Sprintf(format, args...))
+	}
+
+	if limit, ok := field.Tag.Lookup("min"); ok {
+		below, err := compare(v, limit)
+		if err != nil {
+			return fmt.Errorf("%s: invalid min tag: %w", field.Key, err)
+		}
+		if below < 0 {
+			return invalid("is less than the minimum %s", limit)
+		}
+	}
+	if limit, ok := field.Tag.Lookup("max"); ok {
+		above, err := compare(v, limit)
+		if err != nil {
+			return fmt.Errorf("%s: invalid max tag: %w", field.Key, err)
+		}
+		if above > 0 {
+			return invalid("is more than the maximum %s", limit)
+		}
+	}
+	if oneof, ok := field.Tag.Lookup("oneof"); ok {
+		allowed := strings.Fields(oneof)
+		if !slices.Contains(allowed, fmt.Sprint(v.Interface())) {
+			return invalid("is not one of %s", strings.Join(allowed, ", "))
+		}
+	}
+	if pattern, ok := field.Tag.Lookup("pattern"); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern tag: %w", field.Key, err)
+		}
+		if v.Kind() != reflect.String || !re.MatchString(v.String()) {
+			return invalid("does not match %s", pattern)
+		}
+	}
+	return nil
+}
+
+// Compares the value, or the length of strings, slices and maps, with `limit`.
+func compare(v reflect.Value, limit string) (int, error) {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return 0, err
+		}
+		return sign(v.Len(), n), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == decode.DurationType {
+			d, err := time.ParseDuration(limit)
+			if err != nil {
+				return 0, err
+			}
+			return sign(v.Int(), int64(d)), nil
+		}
+		n, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return sign(v.Int(), n), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(limit, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return sign(v.Uint(), n), nil
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(limit, 64)
+		if err != nil {
+			return 0, err
+		}
+		return sign(v.Float(), n), nil
+	default:
+		return 0, fmt.Errorf("min and max are not supported for %s", v.Type())
+	}
+}
+
+// Returns -1, 0 or 1 when `a` is less than, equal to or more than `b`.
+func sign[N int | int64 | uint64 | float64](a, b N) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Parts of field names that mark them as secret, e.g. `dbPassword`, `client_secret_value` and `signingKey`.
+var secretNameParts = []string{"password", "passwd", "secret", "token", "credential", "apikey", "privatekey"}
+
+// Fields tagged `secret:"true"` are masked in errors and logs, as are fields with a name containing one of `secretNameParts`,
+// ending with `key`, or with a word like one of `logging.DefaultRedactedFields`, e.g. `userFnr`. Names are matched ignoring case.
+func isSecret(field Field) bool {
+	if secret, _ := strconv.ParseBool(field.Tag.Get("secret")); secret {
+		return true
+	}
+	words := strings.Split(strings.ToLower(envName(field.Key[strings.LastIndex(field.Key, ".")+1:])), "_")
+	name := strings.Join(words, "")
+	if strings.HasSuffix(name, "key") || slices.ContainsFunc(secretNameParts, func(part string) bool { return strings.Contains(name, part) }) {
+		return true
+	}
+	return slices.ContainsFunc(words, func(word string) bool { return slices.Contains(logging.DefaultRedactedFields, word) })
+}
+
+func displayValue(field Field) string {
+	if isSecret(field) {
+		return logging.Mask
+	}
+	v := field.value
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "<nil>"
+		}
+		v = v.Elem()
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return strconv.Quote(s.String())
+	}
+	if p, ok := v.Addr().Interface().(fmt.Stringer); ok {
+		return strconv.Quote(p.String())
+	}
+	return strconv.Quote(fmt.Sprint(v.Interface()))
+}
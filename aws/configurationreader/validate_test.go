@@ -0,0 +1,111 @@
+package configurationreader
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+type ValidatedConfig struct {
+	Name     string        `json:"name" required:"true" pattern:"^[a-z-]+$"`
+	Level    string        `json:"level" default:"info" oneof:"debug info warn error"`
+	Port     int           `json:"port" min:"1" max:"65535"`
+	Timeout  time.Duration `json:"timeout" min:"1s"`
+	Hosts    []string      `json:"hosts" min:"1"`
+	Retries  int           `json:"retries" required:"true"`
+	Password string        `json:"password" min:"12"`
+	ApiKey   string        `json:"apiKey" secret:"true"`
+	Optional int           `json:"optional" min:"1"`
+}
+
+func values(name string, values Values) Source {
+	return NewSource(name, func(ctx context.Context, fields []Field) (Values, error) {
+		return values, nil
+	})
+}
+
+func TestLoad_ValidatesTags(t *testing.T) {
+	cfg, err := Load[ValidatedConfig](context.Background(), []Source{
+		FromDefaults(),
+		values("file", Values{"name": "my-app", "port": "8080", "timeout": "2s", "hosts": "a,b", "retries": "0"}),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "info", cfg.Level)
+	assert.Equal(t, 0, cfg.Retries)
+}
+
+func TestLoad_ListsAllInvalidFieldsWithSources(t *testing.T) {
+	_, err := Load[ValidatedConfig](context.Background(), []Source{
+		FromDefaults(),
+		values("file", Values{"name": "My App", "port": "0", "hosts": "", "password": "hunter2"}),
+		values("env", Values{"level": "trace", "port": "70000", "timeout": "10ms"}),
+	})
+
+	assert.ErrorContains(t, err, `name: value "My App" from file does not match ^[a-z-]+$`)
+	assert.ErrorContains(t, err, `level: value "trace" from env is not one of debug, info, warn, error`)
+	assert.ErrorContains(t, err, `port: value "70000" from env is more than the maximum 65535`)
+	assert.ErrorContains(t, err, `timeout: value "10ms" from env is less than the minimum 1s`)
+	assert.ErrorContains(t, err, `hosts: value "[]" from file is less than the minimum 1`)
+	assert.ErrorContains(t, err, "retries: missing value")
+	assert.ErrorContains(t, err, "password: value *** from file is less than the minimum 12")
+	assert.NotContains(t, err.Error(), "hunter2")
+	assert.NotContains(t, err.Error(), "optional")
+	assert.Equal(t, 7, len(err.(interface{ Unwrap() []error }).Unwrap()))
+}
+
+func TestLoad_LogsRedactedConfiguration(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.Logger
+	log.Logger = zerolog.New(&buf)
+	defer func() { log.Logger = logger }()
+
+	_, err := Load[ValidatedConfig](context.Background(), []Source{
+		FromDefaults(),
+		values("file", Values{"name": "my-app", "hosts": "a", "retries": "3", "password": "correct-horse-battery", "apiKey": "abc123"}),
+	}, LogConfiguration())
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `"name":"\"my-app\" (file)"`)
+	assert.Contains(t, buf.String(), `"level":"\"info\" (default)"`)
+	assert.Contains(t, buf.String(), `"port":"\"0\" (unset)"`)
+	assert.Contains(t, buf.String(), `"password":"*** (file)"`)
+	assert.Contains(t, buf.String(), `"apiKey":"*** (file)"`)
+	assert.NotContains(t, buf.String(), "correct-horse-battery")
+	assert.NotContains(t, buf.String(), "abc123")
+}
+
+func TestIsSecret_MatchesNamesInAnyCase(t *testing.T) {
+	tests := []struct {
+		key    string
+		secret bool
+	}{
+		{"password", true},
+		{"dbPassword", true},
+		{"db.adminPasswd", true},
+		{"client_secret_value", true},
+		{"clientSecret", true},
+		{"AUTH_TOKEN", true},
+		{"refreshTokenUrl", true},
+		{"apiKey", true},
+		{"api_key", true},
+		{"signingKey", true},
+		{"private_key_pem", true},
+		{"awsCredentials", true},
+		{"userFnr", true},
+		{"ssn", true},
+		{"name", false},
+		{"db.host", false},
+		{"sessionTimeout", false},
+		{"keyPrefix", false},
+		{"monkeyCount", false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.secret, isSecret(Field{Key: test.key}), test.key)
+	}
+}
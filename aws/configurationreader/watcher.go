@@ -0,0 +1,211 @@
+package configurationreader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	awsssm "github.com/oslokommune/common-lib-go/aws/awsparameterstore"
+	"github.com/oslokommune/common-lib-go/aws/awssecretsmanager"
+	"github.com/oslokommune/common-lib-go/logging"
+	"github.com/rs/zerolog/log"
+)
+
+// Returns the current version of a watched value, e.g. a parameter version or a secret version ID.
+type Version func(ctx context.Context) (string, error)
+
+// The version of a Parameter Store parameter, from `awsssm.DescribeParameterStoreParameter`.
+func ParameterVersion(client awsssm.DescribeParametersApi, name string) Version {
+	return func(ctx context.Context) (string, error) {
+		output, err := awsssm.DescribeParameterStoreParameter(ctx, client, name)
+		if err != nil {
+			return "", err
+		}
+		if len(output.Parameters) == 0 {
+			return "", fmt.Errorf("parameter %s not found", name)
+		}
+		return strconv.FormatInt(output.Parameters[0].Version, 10), nil
+	}
+}
+
+// The `VersionId` of the current version of a Secrets Manager secret, e.g. after a rotation.
+func SecretVersion(client awssecretsmanager.GetSecretValueApi, name string) Version {
+	return func(ctx context.Context) (string, error) {
+		_, versionId, err := awssecretsmanager.GetSecretWithVersion(ctx, client, name)
+		if err != nil {
+			return "", err
+		}
+		if versionId == nil {
+			return "", nil
+		}
+		return *versionId, nil
+	}
+}
+
+// Keeps a configuration up to date by polling the versions of its sources, and reloading it when a version changes.
+//
+// The configuration is replaced atomically, so `Get` always returns a complete and valid snapshot.
+// A configuration that fails to load or validate is logged and retried at the next poll, keeping the previous snapshot.
+type Watcher[T any] struct {
+	sources  []Source
+	options  []Option
+	versions []Version
+	interval time.Duration
+
+	current atomic.Pointer[T]
+
+	mu          sync.Mutex
+	last        []string
+	subscribers []func(previous, current *T)
+}
+
+// Loads the configuration from `sources`, see `Load`, and returns a watcher reloading it when any of `versions` changes.
+// Versions are polled every `interval`, with up to 10% jitter so instances of a service don't poll in lockstep.
+//
+// Usage:
+// ```go
+//
+//	watcher, err := configurationreader.NewWatcher[Config](ctx,
+//		[]configurationreader.Source{
+//			configurationreader.FromParameter(ssmClient, "/my-app/config"),
+//			configurationreader.FromSecret(secretsClient, "my-app/db"),
+//			configurationreader.FromEnv(),
+//		},
+//		[]configurationreader.Version{
+//			configurationreader.ParameterVersion(ssmClient, "/my-app/config"),
+//			configurationreader.SecretVersion(secretsClient, "my-app/db"),
+//		},
+//		time.Minute,
+//	)
+//	if err != nil {
+//		log.Fatal().Err(err).Msg("Failed to read configuration")
+//	}
+//	watcher.Subscribe(func(previous, current *Config) {
+//		if previous.Database.Password != current.Database.Password {
+//			conn.CloseConnection()
+//		}
+//	})
+//	go watcher.Run(ctx)
+//
+//	cfg := watcher.Get()
+//
+// ```
+func NewWatcher[T any](ctx context.Context, sources []Source, versions []Version, interval time.Duration, options ...Option) (*Watcher[T], error) {
+	w := &Watcher[T]{sources: sources, options: options, versions: versions, interval: interval}
+
+	last, err := w.currentVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := Load[T](ctx, sources, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	w.last = last
+	w.current.Store(cfg)
+	return w, nil
+}
+
+// The current configuration. The snapshot must not be modified, since it's shared with all callers.
+func (w *Watcher[T]) Get() *T {
+	return w.current.Load()
+}
+
+// Calls `fn` with the previous and current configuration after each reload.
+func (w *Watcher[T]) Subscribe(fn func(previous, current *T)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Polls for changes until `ctx` is cancelled.
+func (w *Watcher[T]) Run(ctx context.Context) {
+	for {
+		timer := time.NewTimer(w.nextPoll())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if _, err := w.Check(ctx); err != nil {
+			log.Error().Err(err).Msg("Failed to reload configuration, keeping the current configuration")
+		}
+	}
+}
+
+// Checks the versions once and reloads the configuration if any changed. Returns whether the configuration was reloaded.
+func (w *Watcher[T]) Check(ctx context.Context) (bool, error) {
+	versions, err := w.currentVersions(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	w.mu.Lock()
+	if slices.Equal(versions, w.last) {
+		w.mu.Unlock()
+		return false, nil
+	}
+
+	cfg, err := Load[T](ctx, w.sources, w.options...)
+	if err != nil {
+		w.mu.Unlock()
+		return false, err
+	}
+
+	previous := w.current.Swap(cfg)
+	w.last = versions
+	subscribers := slices.Clone(w.subscribers)
+	w.mu.Unlock()
+	log.Info().Msg("Reloaded configuration")
+
+	// Called without the lock, so subscribers can call `Get`, `Subscribe` and `Check`
+	for _, subscriber := range subscribers {
+		notify(ctx, subscriber, previous, cfg)
+	}
+	return true, nil
+}
+
+// Calls `subscriber`, logging a panic instead of stopping the watcher and the remaining subscribers.
+func notify[T any](ctx context.Context, subscriber func(previous, current *T), previous, current *T) {
+	defer func() {
+		if r := recover(); r != nil {
+			goroutine, stack := logging.GetStack()
+			stacktrace := logging.StackTrace{GoRoutine: goroutine, Stack: stack, Reason: r}
+			stacktrace = stacktrace.SkipFramesAfterPanic()
+			log.Error().Ctx(ctx).Stack().Err(stacktrace).Msg("A configuration subscriber panicked")
+		}
+	}()
+
+	subscriber(previous, current)
+}
+
+func (w *Watcher[T]) currentVersions(ctx context.Context) ([]string, error) {
+	versions := make([]string, len(w.versions))
+	var errs []error
+	for i, version := range w.versions {
+		v, err := version(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("checking configuration version: %w", err))
+			continue
+		}
+		versions[i] = v
+	}
+	return versions, errors.Join(errs...)
+}
+
+func (w *Watcher[T]) nextPoll() time.Duration {
+	jitter := int64(w.interval / 10)
+	if jitter <= 0 {
+		return w.interval
+	}
+	return w.interval - time.Duration(jitter) + time.Duration(rand.Int64N(2*jitter))
+}
@@ -0,0 +1,170 @@
+package configurationreader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type FeatureConfig struct {
+	Enabled  bool   `json:"enabled"`
+	Password string `json:"password" required:"true"`
+}
+
+// Parameter Store and Secrets Manager serving values that can be changed by the test.
+type ChangingStoreMock struct {
+	mu        sync.Mutex
+	parameter string
+	version   int64
+	secret    string
+	versionId string
+}
+
+func (m *ChangingStoreMock) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return &ssm.GetParameterOutput{Parameter: &types.Parameter{Name: params.Name, Value: aws.String(m.parameter), Version: m.version}}, nil
+}
+
+func (m *ChangingStoreMock) DescribeParameters(ctx context.Context, params *ssm.DescribeParametersInput, optFns ...func(*ssm.Options)) (*ssm.DescribeParametersOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return &ssm.DescribeParametersOutput{Parameters: []types.ParameterMetadata{{Name: aws.String(params.ParameterFilters[0].Values[0]), Version: m.version}}}, nil
+}
+
+func (m *ChangingStoreMock) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(m.secret), VersionId: aws.String(m.versionId)}, nil
+}
+
+func (m *ChangingStoreMock) set(fn func(m *ChangingStoreMock)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fn(m)
+}
+
+func newFeatureWatcher(t *testing.T, store *ChangingStoreMock) *Watcher[FeatureConfig] {
+	watcher, err := NewWatcher[FeatureConfig](context.Background(),
+		[]Source{FromParameter(store, "/app/features"), FromSecret(store, "app/db")},
+		[]Version{ParameterVersion(store, "/app/features"), SecretVersion(store, "app/db")},
+		time.Millisecond,
+	)
+	assert.NoError(t, err)
+	return watcher
+}
+
+func TestWatcher_ReloadsWhenVersionChanges(t *testing.T) {
+	store := &ChangingStoreMock{parameter: `{"enabled":false}`, version: 1, secret: `{"password":"first"}`, versionId: "v1"}
+	watcher := newFeatureWatcher(t, store)
+	assert.Equal(t, FeatureConfig{Enabled: false, Password: "first"}, *watcher.Get())
+
+	var notified []FeatureConfig
+	watcher.Subscribe(func(previous, current *FeatureConfig) {
+		notified = append(notified, *previous, *current)
+	})
+
+	reloaded, err := watcher.Check(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, reloaded)
+
+	store.set(func(m *ChangingStoreMock) { m.secret, m.versionId = `{"password":"rotated"}`, "v2" })
+	reloaded, err = watcher.Check(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Equal(t, FeatureConfig{Enabled: false, Password: "rotated"}, *watcher.Get())
+
+	store.set(func(m *ChangingStoreMock) { m.parameter, m.version = `{"enabled":true}`, 2 })
+	reloaded, err = watcher.Check(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Equal(t, FeatureConfig{Enabled: true, Password: "rotated"}, *watcher.Get())
+
+	assert.Equal(t, []FeatureConfig{
+		{Enabled: false, Password: "first"}, {Enabled: false, Password: "rotated"},
+		{Enabled: false, Password: "rotated"}, {Enabled: true, Password: "rotated"},
+	}, notified)
+}
+
+func TestWatcher_NotifiesSubscribersWithoutLock(t *testing.T) {
+	store := &ChangingStoreMock{parameter: `{"enabled":false}`, version: 1, secret: `{"password":"first"}`, versionId: "v1"}
+	watcher := newFeatureWatcher(t, store)
+
+	watcher.Subscribe(func(previous, current *FeatureConfig) {
+		panic("broken subscriber")
+	})
+	var notified []FeatureConfig
+	watcher.Subscribe(func(previous, current *FeatureConfig) {
+		// Deadlocks if the watcher holds its lock while notifying
+		reloaded, err := watcher.Check(context.Background())
+		assert.NoError(t, err)
+		assert.False(t, reloaded)
+		notified = append(notified, *current)
+	})
+
+	store.set(func(m *ChangingStoreMock) { m.parameter, m.version = `{"enabled":true}`, 2 })
+	reloaded, err := watcher.Check(context.Background())
+
+	assert.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Equal(t, []FeatureConfig{{Enabled: true, Password: "first"}}, notified)
+}
+
+func TestWatcher_KeepsSnapshotWhenReloadFails(t *testing.T) {
+	store := &ChangingStoreMock{parameter: `{"enabled":true}`, version: 1, secret: `{"password":"first"}`, versionId: "v1"}
+	watcher := newFeatureWatcher(t, store)
+
+	store.set(func(m *ChangingStoreMock) { m.secret, m.versionId = `{}`, "v2" })
+	reloaded, err := watcher.Check(context.Background())
+	assert.ErrorContains(t, err, "password: missing value")
+	assert.False(t, reloaded)
+	assert.Equal(t, FeatureConfig{Enabled: true, Password: "first"}, *watcher.Get())
+
+	store.set(func(m *ChangingStoreMock) { m.secret, m.versionId = `{"password":"fixed"}`, "v3" })
+	reloaded, err = watcher.Check(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Equal(t, FeatureConfig{Enabled: true, Password: "fixed"}, *watcher.Get())
+}
+
+func TestWatcher_RunPollsUntilCancelled(t *testing.T) {
+	store := &ChangingStoreMock{parameter: `{"enabled":false}`, version: 1, secret: `{"password":"first"}`, versionId: "v1"}
+	watcher := newFeatureWatcher(t, store)
+
+	reloaded := make(chan *FeatureConfig, 1)
+	watcher.Subscribe(func(_, current *FeatureConfig) { reloaded <- current })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		watcher.Run(ctx)
+		close(done)
+	}()
+
+	store.set(func(m *ChangingStoreMock) { m.parameter, m.version = `{"enabled":true}`, 2 })
+	select {
+	case cfg := <-reloaded:
+		assert.True(t, cfg.Enabled)
+	case <-time.After(time.Second):
+		t.Fatal("configuration was not reloaded")
+	}
+
+	cancel()
+	<-done
+}
+
+func TestNewWatcher_ReturnsVersionErrors(t *testing.T) {
+	failing := func(ctx context.Context) (string, error) { return "", errors.New("access denied") }
+
+	_, err := NewWatcher[FeatureConfig](context.Background(), []Source{}, []Version{failing}, time.Minute)
+
+	assert.ErrorContains(t, err, "checking configuration version: access denied")
+}
@@ -0,0 +1,165 @@
+package ginruntime
+
+import (
+	"bytes"
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	encodingBrotli = "br"
+	encodingGzip   = "gzip"
+)
+
+// Content types that are already compressed, and not worth compressing again.
+var defaultExcludedContentTypes = []string{
+	"image/",
+	"video/",
+	"audio/",
+	"font/woff",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/pdf",
+	"application/octet-stream",
+	"text/event-stream",
+}
+
+// Preferred encodings, in order
+var supportedEncodings = []string{encodingBrotli, encodingGzip}
+
+// Gin middleware compressing response bodies with brotli or gzip based on the `Accept-Encoding` request header.
+//
+// Responses smaller than `minSize` bytes, responses that already have a `Content-Encoding`,
+// and responses whose content type starts with one of `excludedContentTypes` are not compressed.
+// Images, audio, video and common archive formats are always excluded.
+func Compression(minSize int, excludedContentTypes ...string) gin.HandlerFunc {
+	excluded := append(append([]string{}, defaultExcludedContentTypes...), excludedContentTypes...)
+
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		writer := newBufferedWriter(c.Writer)
+		c.Writer = writer
+		defer func() { c.Writer = writer.ResponseWriter }()
+
+		c.Next()
+		defer writer.flush()
+
+		if writer.passthrough || !compressible(writer, minSize, excluded) {
+			return
+		}
+
+		header := writer.Header()
+		header.Add("Vary", "Accept-Encoding")
+
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" {
+			return
+		}
+
+		compressed, err := compress(encoding, writer.body.Bytes())
+		if err != nil {
+			log.Warn().Ctx(c.Request.Context()).Err(err).Msgf("Failed to compress response with %s, sending it uncompressed", encoding)
+			return
+		}
+
+		header.Set("Content-Encoding", encoding)
+		header.Del("Content-Length")
+		writer.body.Reset()
+		writer.body.Write(compressed)
+	}
+}
+
+func compressible(w *bufferedWriter, minSize int, excludedContentTypes []string) bool {
+	if w.body.Len() == 0 || w.body.Len() < minSize {
+		return false
+	}
+	if w.status < http.StatusOK || w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+	if w.Header().Get("Content-Encoding") != "" {
+		return false
+	}
+
+	contentType := w.Header().Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	}
+	for _, excluded := range excludedContentTypes {
+		if strings.HasPrefix(contentType, excluded) {
+			return false
+		}
+	}
+	return true
+}
+
+// Picks the preferred supported encoding from an `Accept-Encoding` header, or "" if none are acceptable.
+func negotiateEncoding(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	qualities := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		quality := 1.0
+		for _, param := range fields[1:] {
+			key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if found && key == "q" {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		qualities[name] = quality
+	}
+
+	best, bestQuality := "", 0.0
+	for _, encoding := range supportedEncodings {
+		quality, ok := qualities[encoding]
+		if !ok {
+			quality, ok = qualities["*"]
+		}
+		if ok && quality > bestQuality {
+			best, bestQuality = encoding, quality
+		}
+	}
+	return best
+}
+
+func compress(encoding string, body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch encoding {
+	case encodingBrotli:
+		w := brotli.NewWriterLevel(&buf, brotli.DefaultCompression)
+		if _, err := w.Write(body); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	case encodingGzip:
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(body); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
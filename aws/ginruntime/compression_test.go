@@ -0,0 +1,127 @@
+package ginruntime
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var largeBody = strings.Repeat("compressible ", 200)
+
+func newCompressingEngine(options ...Option) *GinEngine {
+	engine := New(context.Background(), options...)
+	engine.AddRoute(nil, "/large", GET, nil, func(c *gin.Context) {
+		c.String(http.StatusOK, largeBody)
+	})
+	engine.AddRoute(nil, "/small", GET, nil, func(c *gin.Context) {
+		c.String(http.StatusOK, "small")
+	})
+	engine.AddRoute(nil, "/image", GET, nil, func(c *gin.Context) {
+		c.Data(http.StatusOK, "image/png", []byte(largeBody))
+	})
+	return engine
+}
+
+func get(engine *GinEngine, path string, headers map[string]string) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	engine.ServerHttp(res, req)
+	return res
+}
+
+func TestCompression_CompressesWithGzip_WhenAccepted(t *testing.T) {
+	engine := newCompressingEngine(WithCompression(100))
+
+	res := get(engine, "/large", map[string]string{"Accept-Encoding": "gzip"})
+
+	assert.Equal(t, "gzip", res.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", res.Header().Get("Vary"))
+	reader, err := gzip.NewReader(res.Body)
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(reader)
+	assert.Equal(t, largeBody, string(body))
+}
+
+func TestCompression_PrefersBrotli(t *testing.T) {
+	engine := newCompressingEngine(WithCompression(100))
+
+	res := get(engine, "/large", map[string]string{"Accept-Encoding": "gzip, deflate, br"})
+
+	assert.Equal(t, "br", res.Header().Get("Content-Encoding"))
+}
+
+func TestCompression_SkipsSmallAndExcludedResponses(t *testing.T) {
+	engine := newCompressingEngine(WithCompression(100))
+
+	small := get(engine, "/small", map[string]string{"Accept-Encoding": "gzip"})
+	image := get(engine, "/image", map[string]string{"Accept-Encoding": "gzip"})
+
+	assert.Empty(t, small.Header().Get("Content-Encoding"))
+	assert.Equal(t, "small", small.Body.String())
+	assert.Empty(t, image.Header().Get("Content-Encoding"))
+}
+
+func TestCompression_SkipsRejectedEncodings(t *testing.T) {
+	engine := newCompressingEngine(WithCompression(100))
+
+	res := get(engine, "/large", map[string]string{"Accept-Encoding": "br;q=0, gzip;q=0"})
+
+	assert.Empty(t, res.Header().Get("Content-Encoding"))
+	assert.Equal(t, largeBody, res.Body.String())
+}
+
+func TestETag_Returns304_WhenIfNoneMatchMatches(t *testing.T) {
+	engine := newCompressingEngine(WithCompression(100), WithETag())
+
+	first := get(engine, "/large", nil)
+	etag := first.Header().Get("ETag")
+	assert.True(t, strings.HasPrefix(etag, `W/"`))
+
+	second := get(engine, "/large", map[string]string{"If-None-Match": etag, "Accept-Encoding": "gzip"})
+	assert.Equal(t, http.StatusNotModified, second.Code)
+	assert.Empty(t, second.Body.String())
+	assert.Equal(t, etag, second.Header().Get("ETag"))
+}
+
+func TestETag_Returns200_WhenIfNoneMatchDiffers(t *testing.T) {
+	engine := newCompressingEngine(WithETag())
+
+	res := get(engine, "/large", map[string]string{"If-None-Match": `W/"other"`})
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, largeBody, res.Body.String())
+}
+
+func TestServerLambdaProxy_Base64EncodesCompressedBody(t *testing.T) {
+	engine := newCompressingEngine(WithCompression(100))
+	proxy := engine.lambdaProxy().(func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error))
+
+	res, err := proxy(context.Background(), events.APIGatewayV2HTTPRequest{
+		RawPath: "/large",
+		Headers: map[string]string{"accept-encoding": "gzip"},
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: "GET", Path: "/large"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.True(t, res.IsBase64Encoded)
+
+	compressed, err := base64.StdEncoding.DecodeString(res.Body)
+	assert.NoError(t, err)
+	reader, err := gzip.NewReader(strings.NewReader(string(compressed)))
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(reader)
+	assert.Equal(t, largeBody, string(body))
+}
@@ -3,11 +3,16 @@ package ginruntime
 import (
 	"net/http"
 
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/gin-gonic/gin"
 )
 
+const requestIdHeader = "X-Request-Id"
+
 func ErrorHandler() gin.HandlerFunc {
 	return jsonErrorReporter(gin.ErrorTypeAny)
 }
@@ -40,7 +45,46 @@ func jsonErrorReporter(errType gin.ErrorType) gin.HandlerFunc {
 			log.Warn().Ctx(c.Request.Context()).Err(responseErr).Msgf("An error occured, which will cause a %d response", responseErr.Status)
 		}
 
-		c.IndentedJSON(responseErr.Status, gin.H{"error": responseErr.Error()})
-		c.Abort()
+		abortWithApiError(c, responseErr)
+	}
+}
+
+// Writes `err` as a JSON response in the same format as `ErrorHandler` and aborts the request.
+func abortWithApiError(c *gin.Context, err *ApiError) {
+	c.IndentedJSON(err.Status, errorBody(c, err))
+	c.Abort()
+}
+
+// The JSON error response body, including trace and request IDs when available so errors can be looked up in logs.
+func errorBody(c *gin.Context, err *ApiError) gin.H {
+	body := gin.H{"error": err.Error()}
+	if traceId := traceId(c); traceId != "" {
+		body["trace_id"] = traceId
+	}
+	if requestId := requestId(c); requestId != "" {
+		body["request_id"] = requestId
+	}
+	return body
+}
+
+func traceId(c *gin.Context) string {
+	spanCtx := trace.SpanContextFromContext(c.Request.Context())
+	if !spanCtx.HasTraceID() {
+		return ""
+	}
+	return spanCtx.TraceID().String()
+}
+
+// Returns the `X-Request-Id` header, or the API Gateway or Lambda request ID when running as a Lambda.
+func requestId(c *gin.Context) string {
+	if id := c.GetHeader(requestIdHeader); id != "" {
+		return id
+	}
+	if apiGwCtx, ok := core.GetAPIGatewayV2ContextFromContext(c.Request.Context()); ok && apiGwCtx.RequestID != "" {
+		return apiGwCtx.RequestID
+	}
+	if lc, ok := lambdacontext.FromContext(c.Request.Context()); ok {
+		return lc.AwsRequestID
 	}
+	return ""
 }
@@ -62,6 +62,14 @@ func NotFound(reason string) *ApiError {
 	return &ApiError{Status: http.StatusNotFound, Reason: reason}
 }
 
+func Conflict(reason string) *ApiError {
+	return &ApiError{Status: http.StatusConflict, Reason: reason}
+}
+
+func RequestEntityTooLarge(reason string) *ApiError {
+	return &ApiError{Status: http.StatusRequestEntityTooLarge, Reason: reason}
+}
+
 func UnprocessableEntity(reason string) *ApiError {
 	return &ApiError{Status: http.StatusUnprocessableEntity, Reason: reason}
 }
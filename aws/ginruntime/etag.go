@@ -0,0 +1,65 @@
+package ginruntime
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Gin middleware adding a weak `ETag` to successful GET responses,
+// and responding with 304 NOT MODIFIED when it matches the `If-None-Match` request header.
+//
+// Responses that already have an `ETag` header are left as they are.
+func ETag() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		writer := newBufferedWriter(c.Writer)
+		c.Writer = writer
+		defer func() { c.Writer = writer.ResponseWriter }()
+
+		c.Next()
+		defer writer.flush()
+
+		if writer.passthrough || writer.status != http.StatusOK || writer.Header().Get("ETag") != "" {
+			return
+		}
+
+		etag := weakETag(writer.body.Bytes())
+		writer.Header().Set("ETag", etag)
+
+		if etagMatches(c.GetHeader("If-None-Match"), etag) {
+			writer.status = http.StatusNotModified
+			writer.body.Reset()
+			writer.Header().Del("Content-Length")
+			writer.Header().Del("Content-Type")
+		}
+	}
+}
+
+func weakETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return fmt.Sprintf(`W/"%s"`, hex.EncodeToString(sum[:16]))
+}
+
+// Compares an `If-None-Match` header with `etag` using the weak comparison function.
+func etagMatches(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -4,15 +4,12 @@ import (
 	"context"
 	"os"
 	"regexp"
-	"strings"
-	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-contrib/logger"
 	"github.com/gin-gonic/gin"
 	"github.com/oslokommune/common-lib-go/aws/ginruntime/openapi"
-	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
+	"github.com/oslokommune/common-lib-go/logging"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/trace"
 )
@@ -24,6 +21,7 @@ type GinEngine struct {
 	propagator propagation.TextMapPropagator
 	openapi    *openapi.OpenAPI
 	onShutdown []func()
+	reporters  []PanicReporter
 }
 
 func New(ctx context.Context, options ...Option) *GinEngine {
@@ -51,10 +49,14 @@ func New(ctx context.Context, options ...Option) *GinEngine {
 	corsConfig.AddAllowMethods("OPTIONS")
 	engine.Use(cors.New(corsConfig))
 
-	// Recover from panics
-	engine.Use(RecoveryMiddleware)
+	e := &GinEngine{ctx, engine, nil, nil, nil, make([]func(), 0), nil}
+
+	// Recover from panics, reporting to the reporters configured by options
+	engine.Use(func(c *gin.Context) {
+		defer recoverPanic(c, e.reporters)
+		c.Next()
+	})
 
-	e := &GinEngine{ctx, engine, nil, nil, nil, make([]func(), 0)}
 	e.apply(options...)
 	return e
 }
@@ -79,39 +81,8 @@ func IsRunningAsLambda() bool {
 }
 
 func configureLogging() {
-	// Loglevel defaults to info, can be overriden
-	logLevel := zerolog.InfoLevel
-	if l := os.Getenv("LOG_LEVEL"); l != "" {
-		switch l {
-		case "DEBUG":
-			logLevel = zerolog.DebugLevel
-		case "INFO":
-			logLevel = zerolog.InfoLevel
-		case "ERROR":
-			logLevel = zerolog.ErrorLevel
-		case "TRACE":
-			logLevel = zerolog.TraceLevel
-		}
-	}
-	zerolog.SetGlobalLevel(logLevel)
-
-	// Setup logger to correspond with logstash format
-	zerolog.TimeFieldFormat = time.RFC3339
-	zerolog.ErrorStackMarshaler = StackTraceMarshaller
-	zerolog.TimestampFieldName = "@timestamp"
-	zerolog.LevelFieldName = "level"
-	zerolog.MessageFieldName = "message"
-	zerolog.ErrorStackFieldName = "stack_trace"
-
-	zerolog.LevelFieldMarshalFunc = func(l zerolog.Level) string {
-		return strings.ToUpper(l.String())
-	}
-
-	// Add default fields to std logger
-	log.Logger = log.With().Str("app_label", os.Getenv("APP_LABEL")).Caller().Logger()
-
-	// If running locally, do not format as json
-	if !IsRunningAsLambda() {
-		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
-	}
+	logging.Configure(
+		logging.WithStackMarshaler(logging.StackTraceMarshaller),
+		logging.WithLevelSignal(),
+	)
 }
@@ -0,0 +1,96 @@
+package ginruntime
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Runs `f` in a new goroutine and returns a channel receiving its result.
+//
+// A panic in `f` is recovered, logged with `ctx` and delivered on the channel as a `StackTrace` error,
+// instead of crashing the process.
+//
+//	Usage:
+//	```go
+//		result := ginruntime.Go(c.Request.Context(), func(ctx context.Context) error {
+//			return uploadAttachment(ctx, attachment)
+//		})
+//		...
+//		if err := <-result; err != nil {
+//			c.Error(err)
+//		}
+//	```
+func Go(ctx context.Context, f func(ctx context.Context) error) <-chan error {
+	result := make(chan error, 1)
+	go func() {
+		result <- runRecovering(ctx, f)
+	}()
+	return result
+}
+
+// A collection of goroutines working on subtasks of the same request, similar to `errgroup.Group`.
+// Panics in the goroutines are recovered and returned from `Wait` as `StackTrace` errors.
+//
+//	Usage:
+//	```go
+//		group, ctx := ginruntime.NewTaskGroup(c.Request.Context())
+//		for _, key := range keys {
+//			group.Go(func(ctx context.Context) error {
+//				return download(ctx, key)
+//			})
+//		}
+//		if err := group.Wait(); err != nil {
+//			c.Error(err)
+//		}
+//	```
+type TaskGroup struct {
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	wg      sync.WaitGroup
+	errOnce sync.Once
+	err     error
+}
+
+// Creates a `TaskGroup` and a derived context that is cancelled when the first task fails or `Wait` returns.
+func NewTaskGroup(ctx context.Context) (*TaskGroup, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &TaskGroup{ctx: ctx, cancel: cancel}, ctx
+}
+
+// Runs `f` in a new goroutine with the group's context.
+func (g *TaskGroup) Go(f func(ctx context.Context) error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+
+		if err := runRecovering(g.ctx, f); err != nil {
+			g.errOnce.Do(func() {
+				g.err = err
+				g.cancel(err)
+			})
+		}
+	}()
+}
+
+// Waits for all goroutines to finish and returns the first error, if any.
+func (g *TaskGroup) Wait() error {
+	g.wg.Wait()
+	g.cancel(nil)
+	return g.err
+}
+
+func runRecovering(ctx context.Context, f func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			goroutine, stack := GetStack()
+			stacktrace := StackTrace{GoRoutine: goroutine, Stack: stack, Reason: r}
+			stacktrace = stacktrace.SkipFramesAfterPanic()
+			log.Error().Ctx(ctx).Stack().Err(stacktrace).Msg("A panic occurred in a background goroutine")
+			err = stacktrace
+		}
+	}()
+
+	return f(ctx)
+}
@@ -0,0 +1,72 @@
+package ginruntime
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGo_ReturnsStackTrace_WhenGoroutinePanics(t *testing.T) {
+	err := <-Go(context.Background(), func(ctx context.Context) error {
+		panic("boom")
+	})
+
+	var stacktrace StackTrace
+	assert.ErrorAs(t, err, &stacktrace)
+	assert.Equal(t, "boom", stacktrace.Reason)
+}
+
+func TestGo_ReturnsError(t *testing.T) {
+	expected := errors.New("failed")
+
+	err := <-Go(context.Background(), func(ctx context.Context) error {
+		return expected
+	})
+
+	assert.Equal(t, expected, err)
+}
+
+func TestTaskGroup_ReturnsFirstErrorAndCancelsContext(t *testing.T) {
+	group, ctx := NewTaskGroup(context.Background())
+
+	group.Go(func(ctx context.Context) error {
+		panic("boom")
+	})
+	group.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	err := group.Wait()
+	var stacktrace StackTrace
+	assert.ErrorAs(t, err, &stacktrace)
+	assert.Error(t, ctx.Err())
+}
+
+func TestTaskGroup_PanicInHandlerGoroutine_Returns500(t *testing.T) {
+	engine := New(context.Background())
+	engine.AddRoute(nil, "/", GET, nil, func(c *gin.Context) {
+		group, _ := NewTaskGroup(c.Request.Context())
+		group.Go(func(ctx context.Context) error {
+			var m map[string]int
+			m["crash"] = 1
+			return nil
+		})
+		if err := group.Wait(); err != nil {
+			c.Error(err)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	engine.ServerHttp(res, req)
+
+	assert.Equal(t, 500, res.Code)
+}
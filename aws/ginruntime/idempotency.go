@@ -0,0 +1,269 @@
+package ginruntime
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+)
+
+var ErrIdempotencyKeyTakenOver = errors.New("idempotency key was taken over by another request")
+
+const (
+	defaultIdempotencyLockTimeout = time.Minute
+	defaultIdempotencyMaxBodySize = 1024 * 1024
+)
+
+// A stored response for an idempotency key.
+// `Status` is zero while the request holding the key is still in progress, and `ExpiresAt` is then the end of the lock timeout.
+type IdempotencyRecord struct {
+	Key         string      `dynamodbav:"key"`
+	RequestHash string      `dynamodbav:"request_hash"`
+	Status      int         `dynamodbav:"status"`
+	Header      http.Header `dynamodbav:"header"`
+	Body        []byte      `dynamodbav:"body"`
+	// Unix time in seconds, usable as a DynamoDB TTL attribute.
+	ExpiresAt int64 `dynamodbav:"expires_at"`
+	// Identifies the request holding the key, so a request whose lock was taken over can't save its response.
+	Token string `dynamodbav:"token"`
+}
+
+func (r *IdempotencyRecord) Completed() bool {
+	return r.Status != 0
+}
+
+func (r *IdempotencyRecord) expired(now time.Time) bool {
+	return r.ExpiresAt <= now.Unix()
+}
+
+// Storage for idempotency records.
+type IdempotencyStore interface {
+	// Stores `record` as an in-progress lock for `record.Key`.
+	// If an unexpired record already exists for the key, nothing is stored and the existing record is returned.
+	Lock(ctx context.Context, record IdempotencyRecord) (*IdempotencyRecord, error)
+	// Stores the completed response for a key previously acquired with `Lock`.
+	// Returns `ErrIdempotencyKeyTakenOver` if the stored record has another `Token`, because another request took over the key after the lock timeout.
+	Save(ctx context.Context, record IdempotencyRecord) error
+	// Removes the record for `key`, allowing the request to be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// Gin middleware honouring the `Idempotency-Key` header.
+//
+// The first request with a given key is processed as usual and its response status, headers and body are stored.
+// Retries with the same key and payload get the stored response replayed with an `Idempotent-Replayed: true` header.
+// Retries with a different payload, or while the first request is still in progress, get a 409 CONFLICT response.
+// A request that is still in progress after the lock timeout, e.g. because the instance processing it died, no longer blocks retries.
+// Requests without the header are passed through untouched.
+//
+// The body is read into memory to hash it, so requests with a larger body than the max body size get a 413 REQUEST ENTITY TOO LARGE response.
+//
+// Responses with status 5xx, and errors reported through `c.Error`, are not stored, so the request is processed again on retry.
+//
+//	Usage:
+//	```go
+//		store := ginruntime.NewDynamoDBIdempotencyStore(awsdynamodb.NewClient(true), "idempotency")
+//		engine.AddRoute(nil, "/payments", ginruntime.POST, nil, ginruntime.Idempotency(store, 24*time.Hour), handler)
+//	```
+func Idempotency(store IdempotencyStore, ttl time.Duration, options ...IdempotencyOption) gin.HandlerFunc {
+	o := idempotencyOptions{lockTimeout: min(defaultIdempotencyLockTimeout, ttl), maxBodySize: defaultIdempotencyMaxBodySize}
+	for _, option := range options {
+		option(&o)
+	}
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, o.maxBodySize))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				abortWithApiError(c, RequestEntityTooLarge(fmt.Sprintf("request body is larger than %d bytes", tooLarge.Limit)))
+				return
+			}
+			abortWithApiError(c, BadRequest("failed to read request body"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		lockExpiresAt := time.Now().Add(o.lockTimeout)
+		record := IdempotencyRecord{
+			Key:         c.Request.Method + " " + c.FullPath() + " " + key,
+			Token:       uuid.NewString(),
+			RequestHash: hashRequestBody(body),
+			ExpiresAt:   lockExpiresAt.Unix(),
+		}
+
+		existing, err := store.Lock(ctx, record)
+		if err != nil {
+			log.Error().Ctx(ctx).Err(err).Msgf("Failed to lock idempotency key %s", key)
+			abortWithApiError(c, InternalServerError("internal server error"))
+			return
+		}
+
+		if existing != nil {
+			switch {
+			case existing.RequestHash != record.RequestHash:
+				abortWithApiError(c, Conflict("idempotency key has already been used with a different request payload"))
+			case !existing.Completed():
+				abortWithApiError(c, Conflict("a request with the same idempotency key is being processed"))
+			default:
+				replayResponse(c, existing)
+			}
+			return
+		}
+
+		saved := false
+		defer func() {
+			// Also runs when the handler panics, so the key isn't held until it expires.
+			// After the lock timeout a retry may hold the key, so it's left alone.
+			if !saved && time.Now().Before(lockExpiresAt) {
+				if err := store.Release(context.WithoutCancel(ctx), record.Key); err != nil {
+					log.Error().Ctx(ctx).Err(err).Msgf("Failed to release idempotency key %s", key)
+				}
+			}
+		}()
+
+		writer := &responseCapture{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if !writer.Written() || len(c.Errors) > 0 || writer.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		record.Status = writer.Status()
+		record.Header = writer.Header().Clone()
+		record.Body = writer.body.Bytes()
+		record.ExpiresAt = time.Now().Add(ttl).Unix()
+		if err := store.Save(ctx, record); errors.Is(err, ErrIdempotencyKeyTakenOver) {
+			log.Warn().Ctx(ctx).Msgf("Idempotency key %s was taken over after the lock timeout, the response is not stored", key)
+			saved = true
+			return
+		} else if err != nil {
+			log.Error().Ctx(ctx).Err(err).Msgf("Failed to store response for idempotency key %s", key)
+			return
+		}
+		saved = true
+	}
+}
+
+type idempotencyOptions struct {
+	lockTimeout time.Duration
+	maxBodySize int64
+}
+
+type IdempotencyOption func(*idempotencyOptions)
+
+// How long a request in progress holds its key before a retry can take over. Defaults to 1 minute, or the TTL if shorter.
+// Should be longer than the handler's timeout, e.g. the Lambda function timeout.
+func WithLockTimeout(timeout time.Duration) IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.lockTimeout = timeout
+	}
+}
+
+// The largest request body accepted, in bytes. Defaults to 1 MiB.
+func WithMaxBodySize(bytes int64) IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.maxBodySize = bytes
+	}
+}
+
+func replayResponse(c *gin.Context, record *IdempotencyRecord) {
+	for name, values := range record.Header {
+		c.Writer.Header()[name] = values
+	}
+	c.Header(IdempotencyReplayedHeader, "true")
+	c.Writer.WriteHeader(record.Status)
+	if _, err := c.Writer.Write(record.Body); err != nil {
+		log.Warn().Ctx(c.Request.Context()).Err(err).Msg("Failed to write replayed response")
+	}
+	c.Abort()
+}
+
+func hashRequestBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// Response writer keeping a copy of everything written to the underlying writer.
+type responseCapture struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseCapture) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseCapture) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// In-memory `IdempotencyStore`, suitable for tests and single-instance deployments.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]IdempotencyRecord
+}
+
+var _ IdempotencyStore = (*MemoryIdempotencyStore)(nil)
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{records: make(map[string]IdempotencyRecord)}
+}
+
+func (s *MemoryIdempotencyStore) Lock(ctx context.Context, record IdempotencyRecord) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.records[record.Key]; ok && !existing.expired(time.Now()) {
+		return &existing, nil
+	}
+
+	s.records[record.Key] = record
+	return nil, nil
+}
+
+func (s *MemoryIdempotencyStore) Save(ctx context.Context, record IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.records[record.Key]; !ok || existing.Token != record.Token {
+		return ErrIdempotencyKeyTakenOver
+	}
+
+	s.records[record.Key] = record
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
@@ -0,0 +1,70 @@
+package ginruntime
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/oslokommune/common-lib-go/aws/awsdynamodb"
+)
+
+type DynamoDBIdempotencyApi interface {
+	awsdynamodb.DynamoDBPutItemApi
+	awsdynamodb.DynamoDBGetItemApi
+	awsdynamodb.DynamoDBDeleteItemApi
+}
+
+// `IdempotencyStore` backed by a DynamoDB table, so keys are shared across Lambda instances.
+//
+// The table must have a string partition key named `key`.
+// Enable TTL on the `expires_at` attribute to have DynamoDB remove expired records.
+type DynamoDBIdempotencyStore struct {
+	client    DynamoDBIdempotencyApi
+	tablename string
+}
+
+var _ IdempotencyStore = (*DynamoDBIdempotencyStore)(nil)
+
+func NewDynamoDBIdempotencyStore(client DynamoDBIdempotencyApi, tablename string) *DynamoDBIdempotencyStore {
+	return &DynamoDBIdempotencyStore{client: client, tablename: tablename}
+}
+
+func (s *DynamoDBIdempotencyStore) Lock(ctx context.Context, record IdempotencyRecord) (*IdempotencyRecord, error) {
+	// TTL deletion is eventual, so expired records are treated as absent
+	condition := expression.AttributeNotExists(expression.Name("key")).
+		Or(expression.Name("expires_at").LessThanEqual(expression.Value(time.Now().Unix())))
+
+	_, err := awsdynamodb.PutTableItemWithCondition(ctx, s.tablename, s.client, record, condition)
+	if err == nil {
+		return nil, nil
+	}
+	if !awsdynamodb.IsConditionalCheckFailed(err) {
+		return nil, err
+	}
+
+	existing, err := awsdynamodb.GetTableItem[IdempotencyRecord](ctx, s.tablename, s.client, map[string]any{"key": record.Key})
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, fmt.Errorf("idempotency record %s was removed while acquiring it", record.Key)
+	}
+
+	return existing, nil
+}
+
+func (s *DynamoDBIdempotencyStore) Save(ctx context.Context, record IdempotencyRecord) error {
+	condition := expression.Name("token").Equal(expression.Value(record.Token))
+
+	_, err := awsdynamodb.PutTableItemWithCondition(ctx, s.tablename, s.client, record, condition)
+	if awsdynamodb.IsConditionalCheckFailed(err) {
+		return ErrIdempotencyKeyTakenOver
+	}
+	return err
+}
+
+func (s *DynamoDBIdempotencyStore) Release(ctx context.Context, key string) error {
+	_, err := awsdynamodb.DeleteTableItem(ctx, s.tablename, s.client, map[string]any{"key": key})
+	return err
+}
@@ -0,0 +1,164 @@
+package ginruntime
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newIdempotentEngine(calls *int, status int) *GinEngine {
+	engine := New(context.Background())
+	store := NewMemoryIdempotencyStore()
+	engine.AddRoute(nil, "/payments", POST, nil, Idempotency(store, time.Hour), func(c *gin.Context) {
+		*calls++
+		c.Header("X-Payment", "1")
+		c.JSON(status, gin.H{"calls": *calls})
+	})
+	return engine
+}
+
+func postPayment(engine *GinEngine, key string, body string) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/payments", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	engine.ServerHttp(res, req)
+	return res
+}
+
+func TestIdempotency_ReplaysStoredResponse_WhenSameKeyAndPayload(t *testing.T) {
+	calls := 0
+	engine := newIdempotentEngine(&calls, http.StatusCreated)
+
+	first := postPayment(engine, "abc", `{"amount":100}`)
+	second := postPayment(engine, "abc", `{"amount":100}`)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "1", second.Header().Get("X-Payment"))
+	assert.Equal(t, "true", second.Header().Get(IdempotencyReplayedHeader))
+}
+
+func TestIdempotency_Returns409_WhenSameKeyAndDifferentPayload(t *testing.T) {
+	calls := 0
+	engine := newIdempotentEngine(&calls, http.StatusCreated)
+
+	postPayment(engine, "abc", `{"amount":100}`)
+	res := postPayment(engine, "abc", `{"amount":200}`)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusConflict, res.Code)
+}
+
+func TestIdempotency_Returns409_WhenKeyIsInProgress(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	_, _ = store.Lock(context.Background(), IdempotencyRecord{
+		Key:         "POST /payments abc",
+		RequestHash: hashRequestBody([]byte(`{}`)),
+		ExpiresAt:   time.Now().Add(time.Hour).Unix(),
+	})
+
+	engine := New(context.Background())
+	engine.AddRoute(nil, "/payments", POST, nil, Idempotency(store, time.Hour), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	res := postPayment(engine, "abc", `{}`)
+	assert.Equal(t, http.StatusConflict, res.Code)
+}
+
+func TestIdempotency_ProcessesAgain_WhenResponseIsServerError(t *testing.T) {
+	calls := 0
+	engine := newIdempotentEngine(&calls, http.StatusBadGateway)
+
+	postPayment(engine, "abc", `{}`)
+	res := postPayment(engine, "abc", `{}`)
+
+	assert.Equal(t, 2, calls)
+	assert.Empty(t, res.Header().Get(IdempotencyReplayedHeader))
+}
+
+func TestIdempotency_PassesThrough_WhenHeaderMissing(t *testing.T) {
+	calls := 0
+	engine := newIdempotentEngine(&calls, http.StatusCreated)
+
+	postPayment(engine, "", `{}`)
+	postPayment(engine, "", `{}`)
+
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_LocksKeyForLockTimeoutAndStoresResponseForTTL(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	var lockExpiresAt int64
+	engine := New(context.Background())
+	engine.AddRoute(nil, "/payments", POST, nil, Idempotency(store, time.Hour, WithLockTimeout(time.Minute)), func(c *gin.Context) {
+		lockExpiresAt = store.records["POST /payments abc"].ExpiresAt
+		c.String(http.StatusCreated, "created")
+	})
+
+	postPayment(engine, "abc", `{}`)
+
+	assert.InDelta(t, time.Now().Add(time.Minute).Unix(), lockExpiresAt, 2)
+	assert.InDelta(t, time.Now().Add(time.Hour).Unix(), store.records["POST /payments abc"].ExpiresAt, 2)
+}
+
+func TestIdempotency_TakesOverLock_AfterLockTimeout(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	_, _ = store.Lock(context.Background(), IdempotencyRecord{
+		Key:         "POST /payments abc",
+		RequestHash: hashRequestBody([]byte(`{}`)),
+		ExpiresAt:   time.Now().Add(-time.Second).Unix(),
+	})
+
+	engine := New(context.Background())
+	engine.AddRoute(nil, "/payments", POST, nil, Idempotency(store, time.Hour), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	res := postPayment(engine, "abc", `{}`)
+	assert.Equal(t, http.StatusCreated, res.Code)
+}
+
+func TestIdempotency_Returns413_WhenBodyIsTooLarge(t *testing.T) {
+	calls := 0
+	engine := New(context.Background())
+	engine.AddRoute(nil, "/payments", POST, nil, Idempotency(NewMemoryIdempotencyStore(), time.Hour, WithMaxBodySize(8)), func(c *gin.Context) {
+		calls++
+		c.Status(http.StatusCreated)
+	})
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, postPayment(engine, "abc", `{"amount":100}`).Code)
+	assert.Equal(t, http.StatusCreated, postPayment(engine, "abc", `{}`).Code)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotency_KeepsTakeoverResponse_WhenOriginalFinishesLate(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	engine := New(context.Background())
+	calls := 0
+	engine.AddRoute(nil, "/payments", POST, nil, Idempotency(store, time.Hour, WithLockTimeout(0)), func(c *gin.Context) {
+		calls++
+		call := calls
+		if call == 1 {
+			// The retry takes over the expired lock and completes before the original request
+			assert.Equal(t, http.StatusCreated, postPayment(engine, "abc", `{}`).Code)
+		}
+		c.JSON(http.StatusCreated, gin.H{"call": call})
+	})
+
+	postPayment(engine, "abc", `{}`)
+	replayed := postPayment(engine, "abc", `{}`)
+
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, "true", replayed.Header().Get(IdempotencyReplayedHeader))
+	assert.JSONEq(t, `{"call":2}`, replayed.Body.String())
+}
@@ -3,6 +3,7 @@ package ginruntime
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/oslokommune/common-lib-go/aws/ginruntime/openapi"
+	"github.com/oslokommune/common-lib-go/logging"
 	"github.com/rs/zerolog/log"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-lambda-go/otellambda/xrayconfig"
 	"go.opentelemetry.io/contrib/propagators/aws/xray"
@@ -24,9 +25,25 @@ type TracingOptions struct {
 	propagator propagation.TextMapPropagator
 }
 
+type CompressionOptions struct {
+	minSize              int
+	excludedContentTypes []string
+}
+
+type ETagOptions struct{}
+
+type LogLevelOptions struct {
+	path       string
+	middleware []gin.HandlerFunc
+}
+
 type Option struct {
-	openapi *OpenAPIOptions
-	tracing *TracingOptions
+	openapi     *OpenAPIOptions
+	tracing     *TracingOptions
+	compression *CompressionOptions
+	etag        *ETagOptions
+	reporters   []PanicReporter
+	logLevel    *LogLevelOptions
 }
 
 // Enables OpenAPI endpoint `/openapi.json` and Swagger UI endpoint `/docs`.
@@ -77,7 +94,44 @@ func WithTracing(
 	}
 }
 
+// Compresses response bodies of at least `minSize` bytes with brotli or gzip, based on the `Accept-Encoding` request header.
+// Responses with a content type starting with one of `excludedContentTypes` are sent uncompressed.
+//
+// When running as a Lambda, compressed bodies are base64 encoded in the API Gateway response.
+func WithCompression(minSize int, excludedContentTypes ...string) Option {
+	return Option{
+		compression: &CompressionOptions{minSize, excludedContentTypes},
+	}
+}
+
+// Adds weak ETags to GET responses and responds with 304 NOT MODIFIED when `If-None-Match` matches.
+func WithETag() Option {
+	return Option{
+		etag: &ETagOptions{},
+	}
+}
+
+// Adds an endpoint at `path` for reading (GET) and changing (PUT) the log level at runtime, see `logging.LevelHandler`.
+//
+// The endpoint does no authorization by itself, so add `middleware` restricting access to it.
+func WithLogLevelEndpoint(path string, middleware ...gin.HandlerFunc) Option {
+	return Option{
+		logLevel: &LogLevelOptions{path, middleware},
+	}
+}
+
+// Reports panics recovered from route handlers to `reporters`, in addition to logging them.
+func WithPanicReporters(reporters ...PanicReporter) Option {
+	return Option{
+		reporters: reporters,
+	}
+}
+
 func (e *GinEngine) apply(options ...Option) {
+	for _, option := range options {
+		e.reporters = append(e.reporters, option.reporters...)
+	}
+
 	for _, option := range options {
 		if option.tracing != nil {
 			log.Info().Msg("Enabling tracing")
@@ -85,6 +139,28 @@ func (e *GinEngine) apply(options ...Option) {
 		}
 	}
 
+	// Compression is added first, so ETags are computed from the uncompressed body
+	for _, option := range options {
+		if option.compression != nil {
+			log.Info().Msgf("Enabling compression of responses larger than %d bytes", option.compression.minSize)
+			e.Use(Compression(option.compression.minSize, option.compression.excludedContentTypes...))
+		}
+	}
+
+	for _, option := range options {
+		if option.etag != nil {
+			log.Info().Msg("Enabling ETags")
+			e.Use(ETag())
+		}
+	}
+
+	for _, option := range options {
+		if option.logLevel != nil {
+			log.Info().Msgf("Enabling log level endpoint %s", option.logLevel.path)
+			e.enableLogLevelEndpoint(option.logLevel)
+		}
+	}
+
 	for _, option := range options {
 		if option.openapi != nil {
 			log.Info().Msgf("Enabling OpenAPI serving static files from %s", option.openapi.swaggerUiDistUrl)
@@ -141,3 +217,9 @@ func (e *GinEngine) enableOpenAPI(options *OpenAPIOptions) {
 	e.AddRoute(nil, "/openapi.json", GET, nil, append(options.middleware, e.openapi.JsonSpecRoute)...)
 	e.AddRoute(nil, "/docs", GET, nil, append(options.middleware, e.openapi.UiRoute)...)
 }
+
+func (e *GinEngine) enableLogLevelEndpoint(options *LogLevelOptions) {
+	handlers := append(options.middleware, gin.WrapH(logging.LevelHandler()))
+	e.AddRoute(nil, options.path, GET, nil, handlers...)
+	e.AddRoute(nil, options.path, PUT, nil, handlers...)
+}
@@ -0,0 +1,289 @@
+package ginruntime
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/oslokommune/common-lib-go/aws/awssns"
+	"github.com/oslokommune/common-lib-go/httpcomm"
+	"github.com/oslokommune/common-lib-go/logging"
+	"github.com/rs/zerolog/log"
+)
+
+const panicReportTimeout = 5 * time.Second
+
+// A recovered panic and the request it occurred in.
+type PanicReport struct {
+	StackTrace StackTrace
+	Method     string
+	Path       string
+	RequestId  string
+	TraceId    string
+	Time       time.Time
+	// Number of identical panics left unreported since this panic was last reported, see `RateLimitedPanicReporter`.
+	Suppressed int
+}
+
+// Identifies panics with the same reason raised from the same location.
+func (r PanicReport) Fingerprint() string {
+	location := ""
+	if len(r.StackTrace.Stack) > 0 {
+		frame := r.StackTrace.Stack[0]
+		location = fmt.Sprintf("%s:%s", frame.File, frame.Line)
+	}
+	return fmt.Sprintf("%v@%s", r.StackTrace.Reason, location)
+}
+
+// Sends recovered panics somewhere they will be noticed, e.g. an SNS topic or an error tracker.
+type PanicReporter interface {
+	Report(ctx context.Context, report PanicReport) error
+}
+
+// Runs the reporters in the background, so a slow reporter doesn't hold back the response.
+// In Lambda the background goroutine is frozen with the function after the response, so reports may be sent on the next invocation.
+func reportPanic(ctx context.Context, reporters []PanicReporter, report PanicReport) {
+	if len(reporters) == 0 {
+		return
+	}
+
+	// Report even if the request has been cancelled
+	Go(context.WithoutCancel(ctx), func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, panicReportTimeout)
+		defer cancel()
+
+		for _, reporter := range reporters {
+			if err := reporter.Report(ctx, report); err != nil {
+				log.Error().Ctx(ctx).Err(err).Msgf("Failed to report panic with %T", reporter)
+			}
+		}
+		return nil
+	})
+}
+
+// Number of windows a panic with suppressed duplicates is remembered, to include the count when it's reported again.
+const suppressedRetention = 10
+
+type rateLimitedPanicReporter struct {
+	reporter   PanicReporter
+	window     time.Duration
+	mu         sync.Mutex
+	reported   map[string]time.Time
+	suppressed map[string]int
+	pruned     time.Time
+}
+
+// Reports a panic at most once per `window` for each `PanicReport.Fingerprint`.
+// The number of suppressed duplicates is included the next time the panic is reported.
+// Panics are forgotten a window after they were last reported, or after `suppressedRetention` windows if duplicates were suppressed.
+func RateLimitedPanicReporter(reporter PanicReporter, window time.Duration) PanicReporter {
+	return &rateLimitedPanicReporter{
+		reporter:   reporter,
+		window:     window,
+		reported:   make(map[string]time.Time),
+		suppressed: make(map[string]int),
+	}
+}
+
+func (r *rateLimitedPanicReporter) Report(ctx context.Context, report PanicReport) error {
+	fingerprint := report.Fingerprint()
+
+	r.mu.Lock()
+	r.prune(report.Time)
+	if last, ok := r.reported[fingerprint]; ok && report.Time.Sub(last) < r.window {
+		r.suppressed[fingerprint]++
+		r.mu.Unlock()
+		return nil
+	}
+	report.Suppressed = r.suppressed[fingerprint]
+	r.reported[fingerprint] = report.Time
+	delete(r.suppressed, fingerprint)
+	r.mu.Unlock()
+
+	return r.reporter.Report(ctx, report)
+}
+
+// Forgets old panics at most once per window, so the reporter doesn't hold on to every distinct panic it has seen.
+func (r *rateLimitedPanicReporter) prune(now time.Time) {
+	if now.Sub(r.pruned) < r.window {
+		return
+	}
+	for fingerprint, last := range r.reported {
+		retention := r.window
+		if r.suppressed[fingerprint] > 0 {
+			retention *= suppressedRetention
+		}
+		if now.Sub(last) >= retention {
+			delete(r.reported, fingerprint)
+			delete(r.suppressed, fingerprint)
+		}
+	}
+	r.pruned = now
+}
+
+type snsPanicReporter struct {
+	client   awssns.SNSPublishApi
+	topicArn string
+}
+
+// Publishes panics as JSON messages to an SNS topic.
+func NewSNSPanicReporter(client awssns.SNSPublishApi, topicArn string) PanicReporter {
+	return &snsPanicReporter{client, topicArn}
+}
+
+func (r *snsPanicReporter) Report(ctx context.Context, report PanicReport) error {
+	message, err := json.Marshal(map[string]any{
+		"app_label":   os.Getenv("APP_LABEL"),
+		"error":       report.StackTrace.Error(),
+		"method":      report.Method,
+		"path":        report.Path,
+		"request_id":  report.RequestId,
+		"trace_id":    report.TraceId,
+		"time":        report.Time,
+		"suppressed":  report.Suppressed,
+		"stack_trace": logging.StackTraceMarshaller(report.StackTrace),
+	})
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("Panic in %s", os.Getenv("APP_LABEL"))
+	if len(subject) > 100 {
+		// SNS subjects are limited to 100 characters
+		subject = subject[:100]
+	}
+
+	_, err = awssns.PublishToTopic(ctx, r.client, r.topicArn, string(message), subject)
+	return err
+}
+
+type httpPanicReporter struct {
+	client  httpcomm.HttpDoer
+	url     string
+	headers map[string]string
+}
+
+// Posts panics as Sentry-compatible events to `url`.
+//
+// For Sentry, use the store endpoint of the project, e.g. `https://sentry.example.com/api/<project>/store/`,
+// with an `X-Sentry-Auth` header containing the `sentry_key` from the DSN.
+func NewHTTPPanicReporter(client httpcomm.HttpDoer, url string, headers map[string]string) PanicReporter {
+	return &httpPanicReporter{client, url, headers}
+}
+
+type sentryEvent struct {
+	EventId    string            `json:"event_id"`
+	Timestamp  time.Time         `json:"timestamp"`
+	Platform   string            `json:"platform"`
+	Level      string            `json:"level"`
+	ServerName string            `json:"server_name,omitempty"`
+	Release    string            `json:"release,omitempty"`
+	Message    string            `json:"message"`
+	Tags       map[string]string `json:"tags"`
+	Extra      map[string]any    `json:"extra,omitempty"`
+	Request    sentryRequest     `json:"request"`
+	Exception  sentryExceptions  `json:"exception"`
+}
+
+type sentryRequest struct {
+	Method string `json:"method"`
+	Url    string `json:"url"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type       string           `json:"type"`
+	Value      string           `json:"value"`
+	Stacktrace sentryStacktrace `json:"stacktrace"`
+}
+
+type sentryStacktrace struct {
+	Frames []sentryFrame `json:"frames"`
+}
+
+type sentryFrame struct {
+	Filename string `json:"filename"`
+	Function string `json:"function"`
+	Lineno   int    `json:"lineno,omitempty"`
+}
+
+func (r *httpPanicReporter) Report(ctx context.Context, report PanicReport) error {
+	body, err := json.Marshal(newSentryEvent(report))
+	if err != nil {
+		return err
+	}
+
+	headers := map[string]string{"Content-Type": "application/json"}
+	for k, v := range r.headers {
+		headers[k] = v
+	}
+
+	_, err = httpcomm.Call(ctx, r.client, httpcomm.HTTPRequest{
+		Body:    bytes.NewReader(body),
+		Headers: headers,
+		Url:     r.url,
+		Method:  http.MethodPost,
+	})
+	return err
+}
+
+func newSentryEvent(report PanicReport) sentryEvent {
+	// Sentry expects the oldest frame first
+	stack := report.StackTrace.Stack
+	frames := make([]sentryFrame, len(stack))
+	for i, frame := range stack {
+		line, _ := strconv.Atoi(frame.Line)
+		frames[len(stack)-1-i] = sentryFrame{Filename: frame.File, Function: frame.Function, Lineno: line}
+	}
+
+	tags := map[string]string{"app_label": os.Getenv("APP_LABEL")}
+	if report.RequestId != "" {
+		tags["request_id"] = report.RequestId
+	}
+	if report.TraceId != "" {
+		tags["trace_id"] = report.TraceId
+	}
+
+	var extra map[string]any
+	if report.Suppressed > 0 {
+		extra = map[string]any{"suppressed": report.Suppressed}
+	}
+
+	serverName, _ := os.Hostname()
+	reason := fmt.Sprintf("%v", report.StackTrace.Reason)
+
+	return sentryEvent{
+		EventId:    newEventId(),
+		Timestamp:  report.Time.UTC(),
+		Platform:   "go",
+		Level:      "fatal",
+		ServerName: serverName,
+		Release:    os.Getenv("APP_VERSION"),
+		Message:    report.StackTrace.Error(),
+		Tags:       tags,
+		Extra:      extra,
+		Request:    sentryRequest{Method: report.Method, Url: report.Path},
+		Exception: sentryExceptions{Values: []sentryException{{
+			Type:       "panic",
+			Value:      reason,
+			Stacktrace: sentryStacktrace{Frames: frames},
+		}}},
+	}
+}
+
+func newEventId() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -1,139 +1,75 @@
 package ginruntime
 
 import (
-	"fmt"
-	"regexp"
-	"runtime/debug"
-	"strings"
+	"errors"
+	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 )
 
-type GoRoutine = string
-
-type Frame struct {
-	File     string
-	Line     string
-	Function string
-}
-
-type StackTrace struct {
-	GoRoutine string
-	Stack     []Frame
-	Reason    any
-}
-
-// Extracts stack trace from a `StackTrace` error and returns it
-// Usage:
-// ```go
-// zerolog.ErrorStackMarshaler = StackTraceMarshaller
-// goroutine, stack := GetStack()
-// stacktrace := StackTrace{GoRoutine: goroutine, Stack: stack, Reason: r}
-// log.Info().Err(stacktrace).Msg("An error occurred")
-// ```
-func StackTraceMarshaller(err error) any {
-	panicErr, ok := err.(StackTrace)
-	if !ok {
-		log.Warn().Err(err).Msg("Cannot extract stack trace from non-StackTrace error")
-		return nil
-	}
-
-	out := make([]map[string]string, len(panicErr.Stack))
-	for i, frame := range panicErr.Stack {
-		out[i] = map[string]string{
-			"file":     frame.File,
-			"line":     frame.Line,
-			"function": frame.Function,
-		}
-	}
-	return out
-}
-
 // Gin middleware for recovering from panics and logging to zerolog.
 // Assumes `StackTraceMarshaller` is being used.
 //
+// The panic is answered with a 500 INTERNAL_SERVER_ERROR response in the same JSON format as `ErrorHandler`.
+//
 //	Usage:
 //	```go
 //		engine.Use(RecoveryMiddleware)
 //	```
 func RecoveryMiddleware(c *gin.Context) {
-	defer func() {
-		if r := recover(); r != nil {
-			goroutine, stack := GetStack()
-			stacktrace := StackTrace{GoRoutine: goroutine, Stack: stack, Reason: r}
-			stacktrace = stacktrace.SkipFramesAfterPanic()
-			log.Error().Ctx(c.Request.Context()).Stack().Err(stacktrace).Msg("A panic occurred, which will cause a 500 INTERNAL_SERVER_ERROR response")
-			c.AbortWithStatus(500)
-		}
-	}()
+	defer recoverPanic(c, nil)
 	c.Next()
 }
 
-func (s StackTrace) Error() string {
-	return fmt.Sprintf("panic in %s: %v+", s.GoRoutine, s.Reason)
-}
-
-func (s StackTrace) Skip(n int) StackTrace {
-	if n < 0 {
-		n = 0
-	}
-	if n >= len(s.Stack) {
-		n = len(s.Stack) - 1
-	}
-
-	return StackTrace{
-		GoRoutine: s.GoRoutine,
-		Stack:     s.Stack[n:],
-		Reason:    s.Reason,
+// Gin middleware for recovering from panics, logging to zerolog and reporting them to `reporters`.
+// Panics are reported in the background after the 500 response is written.
+// Use `RateLimitedPanicReporter` to avoid reporting the same panic over and over again.
+//
+//	Usage:
+//	```go
+//		reporter := ginruntime.NewSNSPanicReporter(awssns.NewClient(true), topicArn)
+//		engine.Use(ginruntime.Recovery(ginruntime.RateLimitedPanicReporter(reporter, time.Hour)))
+//	```
+func Recovery(reporters ...PanicReporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer recoverPanic(c, reporters)
+		c.Next()
 	}
 }
 
-func (s StackTrace) SkipFramesAfterPanic() StackTrace {
-	return s.Skip(s.estimateNumberOfInternalFrames())
-}
-
-func (s StackTrace) estimateNumberOfInternalFrames() int {
-	panicFilePattern := regexp.MustCompile(`^.*runtime/panic.go$`)
-	panicFuncPattern := regexp.MustCompile(`^panic\((.*)\)$`)
-	panicFrameIndex := 0
-	for i := len(s.Stack) - 1; i >= 0; i-- {
-		frame := s.Stack[i]
-		if panicFuncPattern.MatchString(frame.Function) && panicFilePattern.MatchString(frame.File) {
-			panicFrameIndex = i
-			break
-		}
+// Must be called directly by a deferred function
+func recoverPanic(c *gin.Context, reporters []PanicReporter) {
+	r := recover()
+	if r == nil {
+		return
 	}
 
-	if panicFrameIndex+1 < len(s.Stack) {
-		return panicFrameIndex + 1
+	// http.ErrAbortHandler is used to deliberately abort a response, and is handled by net/http
+	if err, ok := r.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+		panic(r)
 	}
-	return 0
-}
 
-// Extracts the current goroutine and stack.
-// The stack includes the `GetStack` function call and its call to `debug.Stack()`.
-func GetStack() (GoRoutine, []Frame) {
-	stack := string(debug.Stack())
-	lines := strings.Split(stack, "\n")
-	goroutine, lines := lines[0], lines[1:]
-	goroutine = strings.TrimSuffix(goroutine, ":")
-	frames := make([]Frame, len(lines)/2)
-
-	for i := 0; i < len(frames); i++ {
-
-		function := lines[2*i]
-		location := lines[2*i+1]
-		location = strings.TrimSpace(location)
-		locationParts := strings.Split(location, ":")
+	ctx := c.Request.Context()
+	goroutine, stack := GetStack()
+	stacktrace := StackTrace{GoRoutine: goroutine, Stack: stack, Reason: r}
+	stacktrace = stacktrace.SkipFramesAfterPanic()
+	log.Error().Ctx(ctx).Stack().Err(stacktrace).Msg("A panic occurred, which will cause a 500 INTERNAL_SERVER_ERROR response")
+
+	report := PanicReport{
+		StackTrace: stacktrace,
+		Method:     c.Request.Method,
+		Path:       c.Request.URL.Path,
+		RequestId:  requestId(c),
+		TraceId:    traceId(c),
+		Time:       time.Now(),
+	}
 
-		line := ""
-		file := locationParts[0]
-		if 1 < len(locationParts) {
-			// Strip away the frame offset ("+0x2a" etc.)
-			line = strings.Split(locationParts[1], " ")[0]
-		}
-		frames[i] = Frame{Function: function, File: file, Line: line}
+	if c.Writer.Written() {
+		c.Abort()
+	} else {
+		abortWithApiError(c, InternalServerError("internal server error"))
 	}
-	return goroutine, frames
+	reportPanic(ctx, reporters, report)
 }
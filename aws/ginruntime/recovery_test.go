@@ -0,0 +1,178 @@
+package ginruntime
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingPanicReporter struct {
+	mu      sync.Mutex
+	reports []PanicReport
+	// Blocks reports until closed if set
+	release chan struct{}
+}
+
+func (r *recordingPanicReporter) Report(ctx context.Context, report PanicReport) error {
+	if r.release != nil {
+		<-r.release
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reports = append(r.reports, report)
+	return nil
+}
+
+func (r *recordingPanicReporter) reported() []PanicReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.reports)
+}
+
+type snsPublishMock struct {
+	input *sns.PublishInput
+}
+
+func (m *snsPublishMock) Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	m.input = params
+	return &sns.PublishOutput{}, nil
+}
+
+type httpDoerMock struct {
+	request *http.Request
+	body    string
+}
+
+func (m *httpDoerMock) Do(req *http.Request) (*http.Response, error) {
+	m.request = req
+	body, _ := io.ReadAll(req.Body)
+	m.body = string(body)
+	return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+}
+
+func newPanickingEngine(options ...Option) *GinEngine {
+	engine := New(context.Background(), options...)
+	engine.AddRoute(nil, "/panic", GET, nil, func(c *gin.Context) {
+		panic("boom")
+	})
+	return engine
+}
+
+func TestRecovery_Returns500WithJsonBody_WhenHandlerPanics(t *testing.T) {
+	engine := newPanickingEngine()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/panic", nil)
+	req.Header.Set(requestIdHeader, "request-1")
+	engine.ServerHttp(res, req)
+
+	var body map[string]string
+	assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &body))
+	assert.Equal(t, 500, res.Code)
+	assert.Equal(t, "Internal Server Error: internal server error", body["error"])
+	assert.Equal(t, "request-1", body["request_id"])
+}
+
+func TestRecovery_ReportsPanicAfterResponding(t *testing.T) {
+	reporter := &recordingPanicReporter{release: make(chan struct{})}
+	engine := newPanickingEngine(WithPanicReporters(reporter))
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/panic", nil)
+	engine.ServerHttp(res, req)
+
+	// The response doesn't wait for the blocked reporter
+	assert.Equal(t, 500, res.Code)
+	assert.Empty(t, reporter.reported())
+
+	close(reporter.release)
+	assert.Eventually(t, func() bool { return len(reporter.reported()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, "boom", reporter.reported()[0].StackTrace.Reason)
+	assert.Equal(t, "/panic", reporter.reported()[0].Path)
+}
+
+func TestRecovery_Repanics_WhenErrAbortHandler(t *testing.T) {
+	engine := New(context.Background())
+	engine.AddRoute(nil, "/abort", GET, nil, func(c *gin.Context) {
+		panic(http.ErrAbortHandler)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/abort", nil)
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() { engine.ServerHttp(res, req) })
+}
+
+func TestRateLimitedPanicReporter_SuppressesDuplicatesWithinWindow(t *testing.T) {
+	reporter := &recordingPanicReporter{}
+	limited := RateLimitedPanicReporter(reporter, time.Minute)
+	now := time.Now()
+	report := PanicReport{StackTrace: StackTrace{Reason: "boom"}, Time: now}
+
+	_ = limited.Report(context.Background(), report)
+	_ = limited.Report(context.Background(), report)
+	_ = limited.Report(context.Background(), PanicReport{StackTrace: StackTrace{Reason: "other"}, Time: now})
+	report.Time = now.Add(2 * time.Minute)
+	_ = limited.Report(context.Background(), report)
+
+	assert.Equal(t, 3, len(reporter.reports))
+	assert.Equal(t, 1, reporter.reports[2].Suppressed)
+}
+
+func TestRateLimitedPanicReporter_ForgetsPanicsAfterWindow(t *testing.T) {
+	limited := RateLimitedPanicReporter(&recordingPanicReporter{}, time.Minute).(*rateLimitedPanicReporter)
+	now := time.Now()
+
+	for i := range 100 {
+		_ = limited.Report(context.Background(), PanicReport{StackTrace: StackTrace{Reason: i}, Time: now})
+	}
+	_ = limited.Report(context.Background(), PanicReport{StackTrace: StackTrace{Reason: 0}, Time: now.Add(30 * time.Second)})
+	assert.Len(t, limited.reported, 100)
+	assert.Len(t, limited.suppressed, 1)
+
+	_ = limited.Report(context.Background(), PanicReport{StackTrace: StackTrace{Reason: "new"}, Time: now.Add(2 * time.Minute)})
+	assert.Len(t, limited.reported, 2)
+	assert.Len(t, limited.suppressed, 1)
+
+	_ = limited.Report(context.Background(), PanicReport{StackTrace: StackTrace{Reason: "new"}, Time: now.Add(11 * time.Minute)})
+	assert.Len(t, limited.reported, 1)
+	assert.Empty(t, limited.suppressed)
+}
+
+func TestSNSPanicReporter_PublishesToTopic(t *testing.T) {
+	client := &snsPublishMock{}
+	reporter := NewSNSPanicReporter(client, "arn:aws:sns:eu-north-1:123456789012:panics")
+
+	err := reporter.Report(context.Background(), PanicReport{StackTrace: StackTrace{Reason: "boom"}, Path: "/panic"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "arn:aws:sns:eu-north-1:123456789012:panics", *client.input.TopicArn)
+	assert.Contains(t, *client.input.Message, `"path":"/panic"`)
+}
+
+func TestHTTPPanicReporter_PostsSentryEvent(t *testing.T) {
+	client := &httpDoerMock{}
+	reporter := NewHTTPPanicReporter(client, "https://sentry.example.com/api/1/store/", map[string]string{"X-Sentry-Auth": "Sentry sentry_key=abc"})
+
+	err := reporter.Report(context.Background(), PanicReport{
+		StackTrace: StackTrace{Reason: "boom", Stack: []Frame{{File: "main.go", Line: "10", Function: "main.handler"}}},
+		Time:       time.Now(),
+	})
+
+	var event sentryEvent
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal([]byte(client.body), &event))
+	assert.Equal(t, "Sentry sentry_key=abc", client.request.Header.Get("X-Sentry-Auth"))
+	assert.Equal(t, "boom", event.Exception.Values[0].Value)
+	assert.Equal(t, 10, event.Exception.Values[0].Stacktrace.Frames[0].Lineno)
+}
@@ -0,0 +1,104 @@
+package ginruntime
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Response writer holding back the status and body until `flush` is called,
+// so middleware can rewrite the response after the handler has run.
+//
+// If the handler flushes explicitly, e.g. when streaming, the buffered content is
+// written immediately and the writer passes everything else through untouched.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status      int
+	written     bool
+	passthrough bool
+	body        bytes.Buffer
+}
+
+func newBufferedWriter(w gin.ResponseWriter) *bufferedWriter {
+	return &bufferedWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code > 0 {
+		w.status = code
+		w.written = true
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	w.written = true
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	if w.passthrough {
+		return w.ResponseWriter.WriteString(s)
+	}
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	if w.passthrough {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	if w.passthrough {
+		return w.ResponseWriter.Size()
+	}
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	if w.passthrough {
+		return w.ResponseWriter.Written()
+	}
+	return w.written
+}
+
+func (w *bufferedWriter) Flush() {
+	if !w.passthrough {
+		w.flush()
+		w.passthrough = true
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Writes the buffered status and body to the underlying writer.
+func (w *bufferedWriter) flush() {
+	if w.passthrough {
+		return
+	}
+	if !w.written {
+		return
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.body.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.body.Bytes())
+	}
+}
@@ -2,6 +2,7 @@ package ginruntime
 
 import (
 	"context"
+	"encoding/base64"
 	"net/http"
 	"net/http/httptest"
 
@@ -16,7 +17,11 @@ func (e *GinEngine) lambdaProxy() any {
 	var proxy any
 
 	proxy = func(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
-		return ginadapter.NewV2(e.engine).ProxyWithContext(ctx, req)
+		res, err := ginadapter.NewV2(e.engine).ProxyWithContext(ctx, req)
+		if err != nil {
+			return res, err
+		}
+		return encodeCompressedBody(res), nil
 	}
 
 	if e.TracingEnabled() {
@@ -26,6 +31,18 @@ func (e *GinEngine) lambdaProxy() any {
 	return proxy
 }
 
+// The proxy only base64 encodes bodies that aren't valid UTF-8.
+// A compressed body must always be base64 encoded for API Gateway to pass it on as binary.
+func encodeCompressedBody(res events.APIGatewayV2HTTPResponse) events.APIGatewayV2HTTPResponse {
+	if res.IsBase64Encoded || res.Headers["Content-Encoding"] == "" {
+		return res
+	}
+
+	res.Body = base64.StdEncoding.EncodeToString([]byte(res.Body))
+	res.IsBase64Encoded = true
+	return res
+}
+
 func (e *GinEngine) StartServer() {
 	defer e.shutdownCallbacks()
 
@@ -0,0 +1,25 @@
+package ginruntime
+
+import (
+	"github.com/oslokommune/common-lib-go/logging"
+)
+
+// Stack traces live in the logging module, so runtimes without gin, like lambdaruntime, can use them without linking gin.
+
+type GoRoutine = logging.GoRoutine
+
+type Frame = logging.Frame
+
+type StackTrace = logging.StackTrace
+
+// Same as `logging.StackTraceMarshaller`.
+func StackTraceMarshaller(err error) any {
+	return logging.StackTraceMarshaller(err)
+}
+
+// Same as `logging.GetStack`. The stack starts with the caller of `GetStack`.
+func GetStack() (GoRoutine, []Frame) {
+	goroutine, stack := logging.GetStack()
+	// Skip this function
+	return goroutine, stack[1:]
+}
@@ -0,0 +1,224 @@
+// Decoding of configuration values from text, shared by `configurationreader` and `awsssm`.
+package decode
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	DurationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// Structs are nested, unless they decode themselves from text like `time.Time` and `localtime.Date`.
+func IsNested(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && !decodesItself(t)
+}
+
+func decodesItself(t reflect.Type) bool {
+	p := reflect.PointerTo(t)
+	return p.Implements(textUnmarshalerType) || p.Implements(jsonUnmarshalerType)
+}
+
+// Structs, slices and maps can't be decoded from text items, so slices and maps of them are decoded from JSON as a whole.
+func isComposite(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return !decodesItself(t)
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return true
+	default:
+		return false
+	}
+}
+
+// Decodes `s` into `v` based on its type.
+//
+// Supported types are strings, bools, integers, floats, `time.Duration`, slices, maps, pointers,
+// and types implementing `encoding.TextUnmarshaler` or `json.Unmarshaler` such as `time.Time` and `localtime.Date`.
+//
+// Slices are read from a JSON array or a comma separated list, and maps from a JSON object or a comma separated list of `key=value` pairs.
+// Slices and maps of structs, slices or maps are read with `encoding/json`.
+func Value(v reflect.Value, s string) error {
+	t := v.Type()
+
+	if decodesItself(t) {
+		// Prefer JSON, since types like `localtime.Date` embed `time.Time` and its `UnmarshalText`
+		p := v.Addr().Interface()
+		if u, ok := p.(json.Unmarshaler); ok {
+			if json.Valid([]byte(s)) {
+				if err := u.UnmarshalJSON([]byte(s)); err == nil {
+					return nil
+				}
+			}
+			quoted, _ := json.Marshal(s)
+			return u.UnmarshalJSON(quoted)
+		}
+		return p.(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if t == DurationType {
+			d, err := time.ParseDuration(strings.TrimSpace(s))
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+		i, err := strconv.ParseInt(strings.TrimSpace(s), 10, t.Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(strings.TrimSpace(s), 10, t.Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), t.Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Pointer:
+		elem := reflect.New(t.Elem())
+		if err := Value(elem.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.Slice:
+		if isComposite(t.Elem()) {
+			return json.Unmarshal([]byte(s), v.Addr().Interface())
+		}
+		items, err := splitList(s)
+		if err != nil {
+			return err
+		}
+		slice := reflect.MakeSlice(t, len(items), len(items))
+		for i, item := range items {
+			if err := Value(slice.Index(i), item); err != nil {
+				return fmt.Errorf("item %d: %w", i, err)
+			}
+		}
+		v.Set(slice)
+	case reflect.Map:
+		if isComposite(t.Elem()) {
+			return json.Unmarshal([]byte(s), v.Addr().Interface())
+		}
+		entries, err := splitMap(s)
+		if err != nil {
+			return err
+		}
+		if err := Map(v, entries); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported type %s", t)
+	}
+	return nil
+}
+
+// Decodes `entries` into the map `v`, adding to existing entries.
+func Map(v reflect.Value, entries map[string]string) error {
+	t := v.Type()
+	if v.IsNil() {
+		v.Set(reflect.MakeMapWithSize(t, len(entries)))
+	}
+	for key, value := range entries {
+		k := reflect.New(t.Key()).Elem()
+		if err := Value(k, key); err != nil {
+			return fmt.Errorf("key %q: %w", key, err)
+		}
+		e := reflect.New(t.Elem()).Elem()
+		if err := Value(e, value); err != nil {
+			return fmt.Errorf("key %q: %w", key, err)
+		}
+		v.SetMapIndex(k, e)
+	}
+	return nil
+}
+
+func splitList(s string) ([]string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(s, "[") {
+		items := strings.Split(s, ",")
+		for i := range items {
+			items[i] = strings.TrimSpace(items[i])
+		}
+		return items, nil
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal([]byte(s), &raw); err != nil {
+		return nil, err
+	}
+	items := make([]string, len(raw))
+	for i, r := range raw {
+		items[i] = jsonText(r)
+	}
+	return items, nil
+}
+
+func splitMap(s string) (map[string]string, error) {
+	s = strings.TrimSpace(s)
+	entries := map[string]string{}
+	if s == "" {
+		return entries, nil
+	}
+	if !strings.HasPrefix(s, "{") {
+		for _, pair := range strings.Split(s, ",") {
+			key, value, found := strings.Cut(pair, "=")
+			if !found {
+				return nil, fmt.Errorf("expected key=value, got %q", pair)
+			}
+			entries[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+		return entries, nil
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(s), &raw); err != nil {
+		return nil, err
+	}
+	for key, value := range raw {
+		entries[key] = jsonText(value)
+	}
+	return entries, nil
+}
+
+// Strings are unquoted, other JSON values are kept as is.
+func jsonText(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return string(raw)
+}
@@ -0,0 +1,93 @@
+package decode
+
+import (
+	"reflect"
+	"strings"
+)
+
+// A field of a config struct, with the struct fields it is nested in.
+type Field struct {
+	// Struct fields from the outermost struct to the field itself, leaving out embedded structs
+	Path  []reflect.StructField
+	Value reflect.Value
+}
+
+// Collects the fields of the struct `v`, recursing into nested structs and allocating nil pointers to them.
+//
+// Fields named `-` by the first of `tags` set on them are skipped, and embedded structs without a name are flattened.
+func Fields(v reflect.Value, tags ...string) []Field {
+	var out []Field
+	collect(v, nil, tags, &out)
+	return out
+}
+
+func collect(v reflect.Value, path []reflect.StructField, tags []string, out *[]Field) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		if !structField.IsExported() {
+			continue
+		}
+
+		name := tagName(structField, tags)
+		if name == "-" {
+			continue
+		}
+		fieldValue := v.Field(i)
+		if name == "" && structField.Anonymous && structField.Type.Kind() == reflect.Struct {
+			collect(fieldValue, path, tags, out)
+			continue
+		}
+
+		fieldPath := append(path[:len(path):len(path)], structField)
+		if IsNested(structField.Type) {
+			if structField.Type.Kind() == reflect.Pointer {
+				if fieldValue.IsNil() {
+					fieldValue.Set(reflect.New(structField.Type.Elem()))
+				}
+				fieldValue = fieldValue.Elem()
+			}
+			collect(fieldValue, fieldPath, tags, out)
+			continue
+		}
+
+		*out = append(*out, Field{Path: fieldPath, Value: fieldValue})
+	}
+}
+
+// Names of the struct fields in the path, from the first of `tags` set on them or the field name, joined by `sep`.
+// Empty if one of them is named `-`.
+func (f Field) Key(sep string, tags ...string) string {
+	names := make([]string, len(f.Path))
+	for i, structField := range f.Path {
+		name := tagName(structField, tags)
+		switch name {
+		case "-":
+			return ""
+		case "":
+			name = structField.Name
+		}
+		names[i] = name
+	}
+	return strings.Join(names, sep)
+}
+
+// The type of the field.
+func (f Field) Type() reflect.Type {
+	return f.Path[len(f.Path)-1].Type
+}
+
+// The tags of the field.
+func (f Field) Tag() reflect.StructTag {
+	return f.Path[len(f.Path)-1].Tag
+}
+
+func tagName(structField reflect.StructField, tags []string) string {
+	for _, tag := range tags {
+		if value, ok := structField.Tag.Lookup(tag); ok {
+			name, _, _ := strings.Cut(value, ",")
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,183 @@
+package lambdaruntime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// Path of the invoke endpoint of the Lambda Runtime Interface Emulator.
+	InvocationsPath = "/2015-03-31/functions/function/invocations"
+
+	defaultLocalPort    = "8080"
+	defaultFixturesDir  = "testdata/events"
+	defaultLocalTimeout = 300 * time.Second
+	functionErrorHeader = "X-Amz-Function-Error"
+	requestIdHeader     = "X-Amzn-RequestId"
+)
+
+type invocationError struct {
+	ErrorMessage string `json:"errorMessage"`
+	ErrorType    string `json:"errorType"`
+}
+
+// Local emulation of the Lambda invoke API, used when the handler isn't running as a Lambda.
+//
+// The handler is invoked with `POST /2015-03-31/functions/function/invocations` (and `POST /` for backwards compatibility)
+// and the serialized response is returned. The context passed to the handler has a `lambdacontext.LambdaContext`
+// and a deadline from the `AWS_LAMBDA_FUNCTION_TIMEOUT` environment variable, in seconds, defaulting to 300.
+//
+// Sample events are replayed with `POST /fixtures/{name}`, which invokes the handler with the file `{name}.json` from
+// the directory in `LAMBDA_FIXTURES_DIR`, defaulting to `testdata/events`.
+func newEmulator[T any, R any](ctx context.Context, handler func(context.Context, T) (R, error)) http.Handler {
+	fixturesDir := os.Getenv("LAMBDA_FIXTURES_DIR")
+	if fixturesDir == "" {
+		fixturesDir = defaultFixturesDir
+	}
+
+	invoke := func(w http.ResponseWriter, r *http.Request) {
+		payload, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeInvocationError(w, http.StatusBadRequest, "", invocationError{err.Error(), "InvalidRequestContentException"})
+			return
+		}
+		invokeLocal(ctx, w, handler, payload)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+InvocationsPath, invoke)
+	mux.HandleFunc("POST /{$}", invoke)
+	mux.HandleFunc("POST /fixtures/{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if !strings.HasSuffix(name, ".json") {
+			name += ".json"
+		}
+		if !filepath.IsLocal(name) {
+			writeInvocationError(w, http.StatusBadRequest, "", invocationError{"invalid fixture name", "InvalidRequestContentException"})
+			return
+		}
+
+		payload, err := os.ReadFile(filepath.Join(fixturesDir, name))
+		if err != nil {
+			writeInvocationError(w, http.StatusNotFound, "", invocationError{err.Error(), "ResourceNotFoundException"})
+			return
+		}
+		invokeLocal(ctx, w, handler, payload)
+	})
+	return mux
+}
+
+func invokeLocal[T any, R any](ctx context.Context, w http.ResponseWriter, handler func(context.Context, T) (R, error), payload []byte) {
+	requestId := uuid.NewString()
+
+	var event T
+	if len(strings.TrimSpace(string(payload))) > 0 {
+		if err := json.Unmarshal(payload, &event); err != nil {
+			writeInvocationError(w, http.StatusBadRequest, requestId, invocationError{err.Error(), "InvalidRequestContentException"})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, localTimeout())
+	defer cancel()
+	ctx = lambdacontext.NewContext(ctx, &lambdacontext.LambdaContext{
+		AwsRequestID:       requestId,
+		InvokedFunctionArn: localFunctionArn(),
+	})
+
+	response, err := handler(ctx, event)
+	if err != nil {
+		w.Header().Set(functionErrorHeader, "Unhandled")
+		writeInvocationError(w, http.StatusOK, requestId, invocationError{err.Error(), errorType(err)})
+		return
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		w.Header().Set(functionErrorHeader, "Unhandled")
+		writeInvocationError(w, http.StatusOK, requestId, invocationError{err.Error(), "Runtime.MarshalError"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(requestIdHeader, requestId)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+func writeInvocationError(w http.ResponseWriter, status int, requestId string, body invocationError) {
+	w.Header().Set("Content-Type", "application/json")
+	if requestId != "" {
+		w.Header().Set(requestIdHeader, requestId)
+	}
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// Same error type as reported by the Lambda runtime, e.g. `*errors.errorString` becomes `errorString`.
+func errorType(err error) string {
+	t := reflect.TypeOf(err)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+func localTimeout() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("AWS_LAMBDA_FUNCTION_TIMEOUT"))
+	if err != nil || seconds <= 0 {
+		return defaultLocalTimeout
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func localFunctionArn() string {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = "eu-west-1"
+	}
+	name := lambdacontext.FunctionName
+	if name == "" {
+		name = "function"
+	}
+	return fmt.Sprintf("arn:aws:lambda:%s:000000000000:function:%s", region, name)
+}
+
+// Serves the local emulator on the port in the `LAMBDA_LOCAL_PORT` environment variable, defaulting to 8080,
+// until the process receives SIGINT or SIGTERM.
+func serveLocal[T any, R any](ctx context.Context, handler func(context.Context, T) (R, error)) {
+	port := os.Getenv("LAMBDA_LOCAL_PORT")
+	if port == "" {
+		port = defaultLocalPort
+	}
+
+	server := &http.Server{Addr: ":" + port, Handler: newEmulator(ctx, handler)}
+	stop, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	go func() {
+		<-stop.Done()
+		_ = server.Shutdown(context.Background())
+	}()
+
+	log.Info().Msgf("starting Lambda emulator for local execution on port %s, invoke with POST %s", port, InvocationsPath)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal().Err(err).Msg("Lambda emulator stopped")
+	}
+	runShutdownHooks()
+}
@@ -0,0 +1,84 @@
+package lambdaruntime
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/stretchr/testify/assert"
+)
+
+type greeting struct {
+	Name string `json:"name"`
+}
+
+type reply struct {
+	Message   string `json:"message"`
+	RequestId string `json:"request_id"`
+}
+
+func greet(ctx context.Context, g greeting) (reply, error) {
+	if g.Name == "" {
+		return reply{}, errors.New("name is required")
+	}
+	lc, _ := lambdacontext.FromContext(ctx)
+	if _, ok := ctx.Deadline(); !ok {
+		return reply{}, errors.New("missing deadline")
+	}
+	return reply{Message: "hello " + g.Name, RequestId: lc.AwsRequestID}, nil
+}
+
+func invokeEmulator(handler http.Handler, path string, body string) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	handler.ServeHTTP(res, req)
+	return res
+}
+
+func TestEmulator_ReturnsSerializedResponse(t *testing.T) {
+	emulator := newEmulator(context.Background(), greet)
+
+	res := invokeEmulator(emulator, InvocationsPath, `{"name":"Oslo"}`)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	requestId := res.Header().Get(requestIdHeader)
+	assert.NotEmpty(t, requestId)
+	assert.JSONEq(t, `{"message":"hello Oslo","request_id":"`+requestId+`"}`, res.Body.String())
+}
+
+func TestEmulator_ReportsDecodeAndHandlerErrors(t *testing.T) {
+	emulator := newEmulator(context.Background(), greet)
+
+	res := invokeEmulator(emulator, "/", `{"name":`)
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+	assert.Contains(t, res.Body.String(), "InvalidRequestContentException")
+
+	res = invokeEmulator(emulator, InvocationsPath, `{}`)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "Unhandled", res.Header().Get(functionErrorHeader))
+	assert.JSONEq(t, `{"errorMessage":"name is required","errorType":"errorString"}`, res.Body.String())
+}
+
+func TestEmulator_ReplaysFixtures(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "oslo.json"), []byte(`{"name":"Oslo"}`), 0o644))
+	t.Setenv("LAMBDA_FIXTURES_DIR", dir)
+	t.Setenv("AWS_LAMBDA_FUNCTION_TIMEOUT", "1")
+	emulator := newEmulator(context.Background(), greet)
+
+	res := invokeEmulator(emulator, "/fixtures/oslo", "")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Contains(t, res.Body.String(), "hello Oslo")
+
+	res = invokeEmulator(emulator, "/fixtures/bergen.json", "")
+	assert.Equal(t, http.StatusNotFound, res.Code)
+
+	assert.Equal(t, time.Second, localTimeout())
+}
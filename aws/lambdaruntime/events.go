@@ -0,0 +1,302 @@
+package lambdaruntime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/oslokommune/common-lib-go/aws/awsdynamodb"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Object referenced by an S3 event notification, with the key URL-decoded.
+type S3Object struct {
+	EventName string
+	EventTime time.Time
+	Bucket    string
+	Key       string
+	Size      int64
+	ETag      string
+	VersionId string
+}
+
+// EventBridge event with the detail decoded into `T`.
+type EventBridgeEvent[T any] struct {
+	Id         string
+	DetailType string
+	Source     string
+	Account    string
+	Region     string
+	Time       time.Time
+	Resources  []string
+	Detail     T
+}
+
+// Change from a DynamoDB stream with the images decoded into `T`. The images are nil when not included in the stream view type,
+// and NewImage is nil for REMOVE events.
+type DynamoDBChange[T any] struct {
+	EventId        string
+	EventName      string
+	SequenceNumber string
+	Keys           map[string]events.DynamoDBAttributeValue
+	NewImage       *T
+	OldImage       *T
+}
+
+type snsEnvelope struct {
+	Type     string `json:"Type"`
+	TopicArn string `json:"TopicArn"`
+	Message  string `json:"Message"`
+}
+
+// Creates a Lambda handler for SQS events which decodes each message body into `T` and calls `handler` once per message.
+// Messages published to SQS through an SNS subscription without raw message delivery are unwrapped, and a `string` `T` receives the body as is.
+// Each message is traced in a span continuing the trace from the message attributes or the `AWSTraceHeader` system attribute.
+//
+// Messages which fail to decode or where `handler` returns an error are reported as partial batch failures,
+// so only those are retried. This requires `ReportBatchItemFailures` to be enabled on the event source mapping.
+// For FIFO queues the remaining messages in a message group after a failure are also reported as failed, to keep them in order.
+func SQSHandler[T any](handler func(ctx context.Context, message T) error) func(context.Context, events.SQSEvent) (events.SQSEventResponse, error) {
+	return func(ctx context.Context, event events.SQSEvent) (events.SQSEventResponse, error) {
+		response := events.SQSEventResponse{BatchItemFailures: []events.SQSBatchItemFailure{}}
+		failedGroups := map[string]bool{}
+
+		for _, record := range event.Records {
+			group := record.Attributes["MessageGroupId"]
+			if group != "" && failedGroups[group] {
+				response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{ItemIdentifier: record.MessageId})
+				continue
+			}
+
+			if err := handleSQSMessage(ctx, record, handler); err != nil {
+				log.Error().Err(err).Str("message_id", record.MessageId).Str("event_source_arn", record.EventSourceARN).Msg("Error handling SQS message")
+				response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{ItemIdentifier: record.MessageId})
+				if group != "" {
+					failedGroups[group] = true
+				}
+			}
+		}
+
+		return response, nil
+	}
+}
+
+func handleSQSMessage[T any](ctx context.Context, record events.SQSMessage, handler func(context.Context, T) error) (err error) {
+	ctx, span := startMessageSpan(ctx, resourceName(record.EventSourceARN)+" process", sqsCarrier(record),
+		attribute.String("messaging.system", "aws_sqs"),
+		attribute.String("messaging.message.id", record.MessageId),
+	)
+	defer func() { endSpan(span, err) }()
+
+	message, err := decodeBody[T](unwrapSNS(record.Body))
+	if err != nil {
+		return fmt.Errorf("decoding message body: %w", err)
+	}
+	return handler(ctx, message)
+}
+
+// Starts a Lambda handling SQS events with `SQSHandler`.
+//
+// Usage:
+// ```go
+//
+//	lambdaruntime.StartSQS(func(ctx context.Context, order Order) error {
+//		return process(ctx, order)
+//	}, lambdaruntime.Recovery, lambdaruntime.Logging)
+//
+// ```
+func StartSQS[T any](handler func(ctx context.Context, message T) error, middleware ...Middleware[events.SQSEvent, events.SQSEventResponse]) {
+	StartHandler(SQSHandler(handler), middleware...)
+}
+
+// Creates a Lambda handler for SNS events which decodes each message into `T`. A `string` `T` receives the message as is.
+// Errors from all records are joined and returned, so the invocation is retried by SNS.
+func SNSHandler[T any](handler func(ctx context.Context, message T) error) func(context.Context, events.SNSEvent) error {
+	return func(ctx context.Context, event events.SNSEvent) error {
+		var errs []error
+		for _, record := range event.Records {
+			ctx, span := startMessageSpan(ctx, resourceName(record.SNS.TopicArn)+" process", snsCarrier(record.SNS),
+				attribute.String("messaging.system", "aws_sns"),
+				attribute.String("messaging.message.id", record.SNS.MessageID),
+			)
+			message, err := decodeBody[T](record.SNS.Message)
+			if err == nil {
+				err = handler(ctx, message)
+			}
+			endSpan(span, err)
+			if err != nil {
+				log.Error().Err(err).Str("message_id", record.SNS.MessageID).Str("topic_arn", record.SNS.TopicArn).Msg("Error handling SNS message")
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
+
+// Starts a Lambda handling SNS events with `SNSHandler`.
+func StartSNS[T any](handler func(ctx context.Context, message T) error, middleware ...Middleware[events.SNSEvent, any]) {
+	StartHandler(withoutResponse(SNSHandler(handler)), middleware...)
+}
+
+// Creates a Lambda handler for S3 event notifications which calls `handler` once per object.
+// Errors from all records are joined and returned.
+func S3Handler(handler func(ctx context.Context, object S3Object) error) func(context.Context, events.S3Event) error {
+	return func(ctx context.Context, event events.S3Event) error {
+		var errs []error
+		for _, record := range event.Records {
+			object, err := s3Object(record)
+			if err == nil {
+				err = handler(ctx, object)
+			}
+			if err != nil {
+				log.Error().Err(err).Str("bucket", record.S3.Bucket.Name).Str("key", record.S3.Object.Key).Msg("Error handling S3 event")
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
+
+// Starts a Lambda handling S3 event notifications with `S3Handler`.
+func StartS3(handler func(ctx context.Context, object S3Object) error, middleware ...Middleware[events.S3Event, any]) {
+	StartHandler(withoutResponse(S3Handler(handler)), middleware...)
+}
+
+func s3Object(record events.S3EventRecord) (S3Object, error) {
+	// Keys in event notifications are URL-encoded with spaces as '+'
+	key, err := url.QueryUnescape(record.S3.Object.Key)
+	if err != nil {
+		return S3Object{}, fmt.Errorf("decoding object key %q: %w", record.S3.Object.Key, err)
+	}
+
+	return S3Object{
+		EventName: record.EventName,
+		EventTime: record.EventTime,
+		Bucket:    record.S3.Bucket.Name,
+		Key:       key,
+		Size:      record.S3.Object.Size,
+		ETag:      record.S3.Object.ETag,
+		VersionId: record.S3.Object.VersionID,
+	}, nil
+}
+
+// Creates a Lambda handler for EventBridge events which decodes the event detail into `T`.
+// The event is traced in a span continuing the trace from top level fields in the detail, e.g. `traceparent`.
+func EventBridgeHandler[T any](handler func(ctx context.Context, event EventBridgeEvent[T]) error) func(context.Context, events.EventBridgeEvent) error {
+	return func(ctx context.Context, event events.EventBridgeEvent) (err error) {
+		ctx, span := startMessageSpan(ctx, event.DetailType+" process", eventBridgeCarrier(event.Detail),
+			attribute.String("messaging.system", "aws_eventbridge"),
+			attribute.String("messaging.message.id", event.ID),
+		)
+		defer func() { endSpan(span, err) }()
+
+		var detail T
+		if len(event.Detail) > 0 {
+			if err := json.Unmarshal(event.Detail, &detail); err != nil {
+				log.Error().Err(err).Str("event_id", event.ID).Str("detail_type", event.DetailType).Msg("Error decoding EventBridge event detail")
+				return fmt.Errorf("decoding event detail: %w", err)
+			}
+		}
+
+		return handler(ctx, EventBridgeEvent[T]{
+			Id:         event.ID,
+			DetailType: event.DetailType,
+			Source:     event.Source,
+			Account:    event.AccountID,
+			Region:     event.Region,
+			Time:       event.Time,
+			Resources:  event.Resources,
+			Detail:     detail,
+		})
+	}
+}
+
+// Starts a Lambda handling EventBridge events with `EventBridgeHandler`.
+func StartEventBridge[T any](handler func(ctx context.Context, event EventBridgeEvent[T]) error, middleware ...Middleware[events.EventBridgeEvent, any]) {
+	StartHandler(withoutResponse(EventBridgeHandler(handler)), middleware...)
+}
+
+// Creates a Lambda handler for DynamoDB stream events which decodes the images of each change into `T`.
+//
+// Records are handled in order. On the first failure the record is reported as a partial batch failure and the rest of the batch is skipped,
+// so Lambda retries from the failed record. This requires `ReportBatchItemFailures` to be enabled on the event source mapping.
+func DynamoDBStreamHandler[T any](handler func(ctx context.Context, change DynamoDBChange[T]) error) func(context.Context, events.DynamoDBEvent) (events.DynamoDBEventResponse, error) {
+	return func(ctx context.Context, event events.DynamoDBEvent) (events.DynamoDBEventResponse, error) {
+		response := events.DynamoDBEventResponse{BatchItemFailures: []events.DynamoDBBatchItemFailure{}}
+
+		for _, record := range event.Records {
+			change, err := dynamoDBChange[T](record)
+			if err == nil {
+				err = handler(ctx, change)
+			}
+			if err != nil {
+				log.Error().Err(err).Str("event_id", record.EventID).Str("sequence_number", record.Change.SequenceNumber).Msg("Error handling DynamoDB stream record")
+				response.BatchItemFailures = append(response.BatchItemFailures, events.DynamoDBBatchItemFailure{ItemIdentifier: record.Change.SequenceNumber})
+				break
+			}
+		}
+
+		return response, nil
+	}
+}
+
+// Starts a Lambda handling DynamoDB stream events with `DynamoDBStreamHandler`.
+func StartDynamoDBStream[T any](handler func(ctx context.Context, change DynamoDBChange[T]) error, middleware ...Middleware[events.DynamoDBEvent, events.DynamoDBEventResponse]) {
+	StartHandler(DynamoDBStreamHandler(handler), middleware...)
+}
+
+func dynamoDBChange[T any](record events.DynamoDBEventRecord) (DynamoDBChange[T], error) {
+	change := DynamoDBChange[T]{
+		EventId:        record.EventID,
+		EventName:      record.EventName,
+		SequenceNumber: record.Change.SequenceNumber,
+		Keys:           record.Change.Keys,
+	}
+
+	if len(record.Change.NewImage) > 0 {
+		change.NewImage = new(T)
+		if err := awsdynamodb.UnmarshalStreamImage(record.Change.NewImage, change.NewImage); err != nil {
+			return change, fmt.Errorf("decoding new image: %w", err)
+		}
+	}
+	if len(record.Change.OldImage) > 0 {
+		change.OldImage = new(T)
+		if err := awsdynamodb.UnmarshalStreamImage(record.Change.OldImage, change.OldImage); err != nil {
+			return change, fmt.Errorf("decoding old image: %w", err)
+		}
+	}
+	return change, nil
+}
+
+// Returns the message of an SNS notification delivered to SQS without raw message delivery, or the body itself.
+func unwrapSNS(body string) string {
+	var envelope snsEnvelope
+	if err := json.Unmarshal([]byte(body), &envelope); err != nil {
+		return body
+	}
+	if envelope.Type == "Notification" && envelope.TopicArn != "" {
+		return envelope.Message
+	}
+	return body
+}
+
+func decodeBody[T any](body string) (T, error) {
+	var message T
+	if s, ok := any(&message).(*string); ok {
+		*s = body
+		return message, nil
+	}
+	err := json.Unmarshal([]byte(body), &message)
+	return message, err
+}
+
+func withoutResponse[T any](handler func(context.Context, T) error) func(context.Context, T) (any, error) {
+	return func(ctx context.Context, event T) (any, error) {
+		return nil, handler(ctx, event)
+	}
+}
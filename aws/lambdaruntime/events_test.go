@@ -0,0 +1,113 @@
+package lambdaruntime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+type order struct {
+	Id     string `json:"id" dynamodbav:"id"`
+	Amount int    `json:"amount" dynamodbav:"amount"`
+}
+
+func TestSQSHandler_ReportsFailedMessages(t *testing.T) {
+	var handled []order
+	handler := SQSHandler(func(ctx context.Context, o order) error {
+		if o.Id == "fail" {
+			return errors.New("failed")
+		}
+		handled = append(handled, o)
+		return nil
+	})
+
+	snsBody, _ := json.Marshal(snsEnvelope{Type: "Notification", TopicArn: "arn:aws:sns:eu-west-1:123:orders", Message: `{"id":"b","amount":2}`})
+	response, err := handler(context.Background(), events.SQSEvent{Records: []events.SQSMessage{
+		{MessageId: "1", Body: `{"id":"a","amount":1}`},
+		{MessageId: "2", Body: string(snsBody)},
+		{MessageId: "3", Body: `{"id":"fail"}`},
+		{MessageId: "4", Body: `not json`},
+	}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []order{{"a", 1}, {"b", 2}}, handled)
+	assert.Equal(t, []events.SQSBatchItemFailure{{ItemIdentifier: "3"}, {ItemIdentifier: "4"}}, response.BatchItemFailures)
+}
+
+func TestSQSHandler_FailsRestOfFifoGroup(t *testing.T) {
+	handler := SQSHandler(func(ctx context.Context, body string) error {
+		if body == "fail" {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	response, _ := handler(context.Background(), events.SQSEvent{Records: []events.SQSMessage{
+		{MessageId: "1", Body: "fail", Attributes: map[string]string{"MessageGroupId": "a"}},
+		{MessageId: "2", Body: "ok", Attributes: map[string]string{"MessageGroupId": "b"}},
+		{MessageId: "3", Body: "ok", Attributes: map[string]string{"MessageGroupId": "a"}},
+	}})
+
+	assert.Equal(t, []events.SQSBatchItemFailure{{ItemIdentifier: "1"}, {ItemIdentifier: "3"}}, response.BatchItemFailures)
+}
+
+func TestS3Handler_DecodesKeys(t *testing.T) {
+	var keys []string
+	handler := S3Handler(func(ctx context.Context, object S3Object) error {
+		keys = append(keys, object.Bucket+"/"+object.Key)
+		return nil
+	})
+
+	event := events.S3Event{Records: []events.S3EventRecord{{S3: events.S3Entity{
+		Bucket: events.S3Bucket{Name: "bucket"},
+		Object: events.S3Object{Key: "reports/m%C3%A5ned+1.pdf"},
+	}}}}
+
+	assert.NoError(t, handler(context.Background(), event))
+	assert.Equal(t, []string{"bucket/reports/måned 1.pdf"}, keys)
+}
+
+func TestEventBridgeHandler_DecodesDetail(t *testing.T) {
+	var received EventBridgeEvent[order]
+	handler := EventBridgeHandler(func(ctx context.Context, event EventBridgeEvent[order]) error {
+		received = event
+		return nil
+	})
+
+	err := handler(context.Background(), events.EventBridgeEvent{ID: "1", DetailType: "OrderCreated", Detail: json.RawMessage(`{"id":"a","amount":3}`)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "OrderCreated", received.DetailType)
+	assert.Equal(t, order{"a", 3}, received.Detail)
+}
+
+func TestDynamoDBStreamHandler_StopsAtFirstFailure(t *testing.T) {
+	var changes []DynamoDBChange[order]
+	handler := DynamoDBStreamHandler(func(ctx context.Context, change DynamoDBChange[order]) error {
+		if change.EventName == "REMOVE" {
+			return errors.New("failed")
+		}
+		changes = append(changes, change)
+		return nil
+	})
+
+	image := map[string]events.DynamoDBAttributeValue{
+		"id":     events.NewStringAttribute("a"),
+		"amount": events.NewNumberAttribute("5"),
+	}
+	response, err := handler(context.Background(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		{EventName: "INSERT", Change: events.DynamoDBStreamRecord{SequenceNumber: "1", NewImage: image}},
+		{EventName: "REMOVE", Change: events.DynamoDBStreamRecord{SequenceNumber: "2", OldImage: image}},
+		{EventName: "INSERT", Change: events.DynamoDBStreamRecord{SequenceNumber: "3", NewImage: image}},
+	}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(changes))
+	assert.Equal(t, &order{"a", 5}, changes[0].NewImage)
+	assert.Nil(t, changes[0].OldImage)
+	assert.Equal(t, []events.DynamoDBBatchItemFailure{{ItemIdentifier: "2"}}, response.BatchItemFailures)
+}
@@ -2,139 +2,52 @@ package lambdaruntime
 
 import (
 	"context"
-	"encoding/json"
-	"net/http"
 	"os"
-	"strings"
-	"time"
 
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
-	"github.com/rs/zerolog/pkgerrors"
-	"go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-lambda-go/otellambda"
-	"go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-lambda-go/otellambda/xrayconfig"
-	"go.opentelemetry.io/contrib/propagators/aws/xray"
-	"go.opentelemetry.io/otel"
+	"github.com/oslokommune/common-lib-go/logging"
 )
 
 func init() {
-	// Loglevel defaults to info, can be overriden
-	logLevel := zerolog.InfoLevel
-	if l := os.Getenv("LOG_LEVEL"); l != "" {
-		switch l {
-		case "DEBUG":
-			logLevel = zerolog.DebugLevel
-		case "INFO":
-			logLevel = zerolog.InfoLevel
-		case "ERROR":
-			logLevel = zerolog.ErrorLevel
-		case "TRACE":
-			logLevel = zerolog.TraceLevel
-		}
-	}
-	zerolog.SetGlobalLevel(logLevel)
-
-	// Setup logger to correspond with logstash format
-	zerolog.TimeFieldFormat = time.RFC3339
-	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
-	zerolog.TimestampFieldName = "@timestamp"
-	zerolog.LevelFieldName = "level"
-	zerolog.MessageFieldName = "message"
-	zerolog.ErrorStackFieldName = "stacktrace"
-
-	zerolog.LevelFieldMarshalFunc = func(l zerolog.Level) string {
-		return strings.ToUpper(l.String())
-	}
-
-	// Add default fields to std logger
-	log.Logger = log.With().Str("app_label", os.Getenv("APP_LABEL")).Caller().Logger()
-
-	// If running locally, do not format as json
-	if !IsRunningAsLambda() {
-		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
-	}
+	logging.Configure(logging.WithStackMarshaler(logging.StackTraceMarshaller))
 }
 
-func errorResponse(w http.ResponseWriter, message string, httpStatusCode int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(httpStatusCode)
-
-	resp := make(map[string]string)
-	resp["message"] = message
-
-	jsonResponse, _ := json.Marshal(resp)
-	w.Write(jsonResponse)
+// Starts a Lambda with `handler` wrapped in `middleware`, see `Chain`.
+// When not running as a Lambda the handler is served by a local emulator of the Lambda invoke API.
+// Hooks registered with `OnInit` run before the handler is started, and hooks registered with `OnShutdown` when the execution environment shuts down.
+func Start[T any, R any](handler func(payload T) (R, error), middleware ...Middleware[T, R]) {
+	StartHandler(func(_ context.Context, payload T) (R, error) {
+		return handler(payload)
+	}, middleware...)
 }
 
-func localProxy[T any, R any](handler func(payload T) (R, error)) func(w http.ResponseWriter, r *http.Request) {
-	return func(w http.ResponseWriter, r *http.Request) {
-		var t T
-		decoder := json.NewDecoder(r.Body)
-		decoder.DisallowUnknownFields()
-		_ = decoder.Decode(&t)
-
-		_, err := handler(t)
-		if err != nil {
-			errorResponse(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-	}
-}
-
-func localProxyWithContext[T any, R any](ctx context.Context, handler func(ctx context.Context, payload T) (R, error)) func(w http.ResponseWriter, r *http.Request) {
-	return func(w http.ResponseWriter, r *http.Request) {
-		var t T
-		decoder := json.NewDecoder(r.Body)
-		decoder.DisallowUnknownFields()
-		_ = decoder.Decode(&t)
-
-		_, err := handler(ctx, t)
-		if err != nil {
-			errorResponse(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-	}
+// Same as `Start`, for handlers taking a context.
+//
+// Usage:
+// ```go
+// lambdaruntime.StartHandler(handle, lambdaruntime.Recovery, lambdaruntime.Logging, lambdaruntime.TimeoutHeadroom[Event, Response](time.Second))
+// ```
+func StartHandler[T any, R any](handler func(ctx context.Context, payload T) (R, error), middleware ...Middleware[T, R]) {
+	ctx := context.Background()
+	runInitHooks(ctx)
+	start(ctx, Chain(handler, middleware...))
 }
 
-func Start[T any, R any](handler func(payload T) (R, error)) {
-	if !IsRunningAsLambda() {
-		log.Info().Msg("starting web proxy for local execution")
-		proxyHandler := http.HandlerFunc(localProxy[T, R](handler))
-		http.Handle("/", proxyHandler)
-		log.Fatal().Err(http.ListenAndServe(":8080", nil))
-	} else {
-		lambda.Start(handler)
+// Same as `StartHandler`, creating the handler with `ctx`.
+// When `tracing` is true invocations are traced with AWS X-Ray, see `XRayTracing`. Use the `Tracing` middleware for other tracer providers.
+func StartWithContext[T any, R any](ctx context.Context, createHandler func(context.Context) func(context.Context, T) (R, error), tracing bool, middleware ...Middleware[T, R]) {
+	runInitHooks(ctx)
+	if tracing && IsRunningAsLambda() {
+		middleware = append([]Middleware[T, R]{XRayTracing[T, R](ctx)}, middleware...)
 	}
+	start(ctx, Chain(createHandler(ctx), middleware...))
 }
 
-func StartWithContext[T any, R any](ctx context.Context, createHandler func(context.Context) func(context.Context, T) (R, error), tracing bool) {
+func start[T any, R any](ctx context.Context, handler Handler[T, R]) {
 	if !IsRunningAsLambda() {
-		log.Info().Msg("starting web proxy for local execution")
-		proxyHandler := http.HandlerFunc(localProxyWithContext[T, R](ctx, createHandler(ctx)))
-		http.Handle("/", proxyHandler)
-		log.Fatal().Err(http.ListenAndServe(":8080", nil))
+		serveLocal(ctx, handler)
 	} else {
-		if tracing {
-			tp, err := xrayconfig.NewTracerProvider(ctx)
-			if err != nil {
-				log.Panic().Err(err).Msg("Error creating trace provider")
-			}
-
-			otel.SetTracerProvider(tp)
-			otel.SetTextMapPropagator(xray.Propagator{})
-
-			defer func(ctx context.Context) {
-				err := tp.Shutdown(ctx)
-				if err != nil {
-					log.Error().Err(err).Msg("Error shutting down tracer provider")
-				}
-			}(ctx)
-
-			lambda.Start(otellambda.InstrumentHandler(createHandler(ctx), xrayconfig.WithRecommendedOptions(tp)...))
-		} else {
-			lambda.Start(createHandler(ctx))
-		}
+		lambda.StartWithOptions(handler, lambda.WithContext(ctx), lambda.WithEnableSIGTERM(runShutdownHooks))
 	}
 }
 
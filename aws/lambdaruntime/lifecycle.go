@@ -0,0 +1,85 @@
+package lambdaruntime
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	hooksMu        sync.Mutex
+	initHooks      []func(ctx context.Context) error
+	coldStartHooks []func(ctx context.Context)
+	shutdownHooks  []func()
+)
+
+// Registers a function run once before the Lambda starts receiving invocations, e.g. to open database connections.
+// Hooks run in the order they are registered, and an error fails the initialization of the execution environment.
+func OnInit(f func(ctx context.Context) error) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	initHooks = append(initHooks, f)
+}
+
+// Registers a function run at the start of the first invocation in the execution environment, with the invocation context.
+// Only run for handlers composed with `Chain`, which includes all handlers started by this package.
+func OnColdStart(f func(ctx context.Context)) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	coldStartHooks = append(coldStartHooks, f)
+}
+
+// Registers a function run when the execution environment is shut down, like `GinEngine.OnShutdown`.
+// Hooks run in the reverse order they are registered, and should finish within 500 ms.
+//
+// Usage:
+// ```go
+// conn := db.New(conf)
+// lambdaruntime.OnShutdown(conn.CloseConnection)
+// lambdaruntime.OnShutdown(mqClient.Disconnect)
+// ```
+//
+// Lambda only sends SIGTERM on shutdown when an extension is registered, which `lambda.WithEnableSIGTERM` does.
+// When running locally the hooks run on SIGINT or SIGTERM.
+func OnShutdown(f func()) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	shutdownHooks = append(shutdownHooks, f)
+}
+
+func runInitHooks(ctx context.Context) {
+	hooksMu.Lock()
+	hooks := initHooks
+	initHooks = nil
+	hooksMu.Unlock()
+
+	for _, f := range hooks {
+		if err := f(ctx); err != nil {
+			log.Fatal().Err(err).Msg("Error initializing Lambda")
+		}
+	}
+}
+
+func runColdStartHooks(ctx context.Context) {
+	hooksMu.Lock()
+	hooks := coldStartHooks
+	coldStartHooks = nil
+	hooksMu.Unlock()
+
+	for _, f := range hooks {
+		f(ctx)
+	}
+}
+
+func runShutdownHooks() {
+	hooksMu.Lock()
+	hooks := shutdownHooks
+	shutdownHooks = nil
+	hooksMu.Unlock()
+
+	log.Info().Msg("Lambda execution environment shutting down")
+	for _, f := range hooks {
+		defer f()
+	}
+}
@@ -0,0 +1,33 @@
+package lambdaruntime
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHooks_RunInOrder(t *testing.T) {
+	var calls []string
+	OnInit(func(ctx context.Context) error { calls = append(calls, "init"); return nil })
+	OnShutdown(func() { calls = append(calls, "close db") })
+	OnShutdown(func() { calls = append(calls, "flush traces") })
+
+	runInitHooks(context.Background())
+	runShutdownHooks()
+	runShutdownHooks()
+
+	assert.Equal(t, []string{"init", "flush traces", "close db"}, calls)
+}
+
+func TestOnColdStart_RunsOnFirstInvocation(t *testing.T) {
+	coldStart.Store(true)
+	var coldStarts []bool
+	OnColdStart(func(ctx context.Context) { coldStarts = append(coldStarts, IsColdStart(ctx)) })
+
+	handler := Chain(func(ctx context.Context, event string) (string, error) { return event, nil })
+	_, _ = handler(context.Background(), "first")
+	_, _ = handler(context.Background(), "second")
+
+	assert.Equal(t, []bool{true}, coldStarts)
+}
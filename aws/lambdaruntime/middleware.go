@@ -0,0 +1,223 @@
+package lambdaruntime
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/oslokommune/common-lib-go/logging"
+	"github.com/rs/zerolog/log"
+)
+
+type Handler[T any, R any] func(ctx context.Context, event T) (R, error)
+
+// Wraps a handler, e.g. to add logging or recover from panics, like gin middleware.
+type Middleware[T any, R any] func(next Handler[T, R]) Handler[T, R]
+
+type coldStartKey struct{}
+
+var coldStart atomic.Bool
+
+func init() {
+	coldStart.Store(true)
+}
+
+// Where `Metrics` writes embedded metric format documents. Lambda sends stdout to CloudWatch Logs, which extracts the metrics.
+var MetricsOutput io.Writer = os.Stdout
+
+// Composes `middleware` around `handler`. The first middleware is the outermost, so it sees the event first.
+//
+// Usage:
+// ```go
+// handler := lambdaruntime.Chain(handle, lambdaruntime.Recovery, lambdaruntime.Logging, lambdaruntime.Metrics[Event, Response]("my-service"))
+// ```
+func Chain[T any, R any](handler func(context.Context, T) (R, error), middleware ...Middleware[T, R]) Handler[T, R] {
+	h := Handler[T, R](handler)
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+
+	return func(ctx context.Context, event T) (R, error) {
+		cold := coldStart.Swap(false)
+		ctx = context.WithValue(ctx, coldStartKey{}, cold)
+		if cold {
+			runColdStartHooks(ctx)
+		}
+		return h(ctx, event)
+	}
+}
+
+// Reports whether the invocation is the first in this execution environment. Only set for handlers composed with `Chain`.
+func IsColdStart(ctx context.Context) bool {
+	cold, _ := ctx.Value(coldStartKey{}).(bool)
+	return cold
+}
+
+// Middleware recovering from panics. The panic is logged with a stack trace in the same format as `ginruntime.RecoveryMiddleware`,
+// and returned as a `logging.StackTrace` error so the invocation fails instead of crashing the runtime.
+func Recovery[T any, R any](next Handler[T, R]) Handler[T, R] {
+	return func(ctx context.Context, event T) (response R, err error) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+
+			goroutine, stack := logging.GetStack()
+			stacktrace := logging.StackTrace{GoRoutine: goroutine, Stack: stack, Reason: r}.SkipFramesAfterPanic()
+			log.Error().Ctx(ctx).Stack().Err(stacktrace).Msg("A panic occurred, which will fail the invocation")
+
+			var zero R
+			response, err = zero, stacktrace
+		}()
+
+		return next(ctx, event)
+	}
+}
+
+// Middleware adding the request ID, function name and cold start flag to the logger in the context, see `zerolog.Ctx`,
+// and logging the outcome and duration of each invocation.
+func Logging[T any, R any](next Handler[T, R]) Handler[T, R] {
+	return func(ctx context.Context, event T) (R, error) {
+		logContext := log.With().Str("function_name", lambdacontext.FunctionName).Bool("cold_start", IsColdStart(ctx))
+		if lc, ok := lambdacontext.FromContext(ctx); ok {
+			logContext = logContext.Str("aws_request_id", lc.AwsRequestID)
+		}
+		logger := logContext.Logger()
+		ctx = logger.WithContext(ctx)
+
+		start := time.Now()
+		response, err := next(ctx, event)
+		duration := time.Since(start)
+
+		if err != nil {
+			logger.Error().Err(err).Dur("duration", duration).Msg("Invocation failed")
+		} else {
+			logger.Info().Dur("duration", duration).Msg("Invocation completed")
+		}
+		return response, err
+	}
+}
+
+// Middleware moving the context deadline `headroom` before the Lambda timeout,
+// so the handler gets a chance to stop and clean up before the invocation is killed.
+func TimeoutHeadroom[T any, R any](headroom time.Duration) Middleware[T, R] {
+	return func(next Handler[T, R]) Handler[T, R] {
+		return func(ctx context.Context, event T) (R, error) {
+			deadline, ok := ctx.Deadline()
+			if !ok {
+				return next(ctx, event)
+			}
+
+			ctx, cancel := context.WithDeadline(ctx, deadline.Add(-headroom))
+			defer cancel()
+
+			response, err := next(ctx, event)
+			if ctx.Err() == context.DeadlineExceeded {
+				log.Warn().Ctx(ctx).Dur("headroom", headroom).Msg("Invocation reached the deadline before the Lambda timeout")
+			}
+			return response, err
+		}
+	}
+}
+
+type metricsKey struct{}
+
+type metricsCollector struct {
+	mu     sync.Mutex
+	values map[string]float64
+	units  map[string]string
+}
+
+// Adds `value` to the metric `name` reported by `Metrics` for the current invocation.
+// Does nothing when the handler isn't wrapped with `Metrics`.
+//
+// Usage:
+// ```go
+// lambdaruntime.AddMetric(ctx, "OrdersProcessed", float64(len(orders)), "Count")
+// ```
+func AddMetric(ctx context.Context, name string, value float64, unit string) {
+	collector, ok := ctx.Value(metricsKey{}).(*metricsCollector)
+	if !ok {
+		return
+	}
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	collector.values[name] += value
+	collector.units[name] = unit
+}
+
+// Middleware reporting Invocations, Errors, ColdStart and Duration metrics, and metrics added with `AddMetric`,
+// to CloudWatch in `namespace` using the embedded metric format, with the function name as dimension.
+func Metrics[T any, R any](namespace string) Middleware[T, R] {
+	return func(next Handler[T, R]) Handler[T, R] {
+		return func(ctx context.Context, event T) (R, error) {
+			collector := &metricsCollector{values: map[string]float64{}, units: map[string]string{}}
+			ctx = context.WithValue(ctx, metricsKey{}, collector)
+
+			start := time.Now()
+			response, err := next(ctx, event)
+
+			AddMetric(ctx, "Invocations", 1, "Count")
+			AddMetric(ctx, "Duration", float64(time.Since(start).Microseconds())/1000, "Milliseconds")
+			if err != nil {
+				AddMetric(ctx, "Errors", 1, "Count")
+			}
+			if IsColdStart(ctx) {
+				AddMetric(ctx, "ColdStart", 1, "Count")
+			}
+			writeMetrics(ctx, namespace, collector, start)
+
+			return response, err
+		}
+	}
+}
+
+type emfMetric struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+type emfDirective struct {
+	Namespace  string      `json:"Namespace"`
+	Dimensions [][]string  `json:"Dimensions"`
+	Metrics    []emfMetric `json:"Metrics"`
+}
+
+type emfMetadata struct {
+	Timestamp         int64          `json:"Timestamp"`
+	CloudWatchMetrics []emfDirective `json:"CloudWatchMetrics"`
+}
+
+func writeMetrics(ctx context.Context, namespace string, collector *metricsCollector, timestamp time.Time) {
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	directive := emfDirective{Namespace: namespace, Dimensions: [][]string{{"FunctionName"}}}
+	document := map[string]any{"FunctionName": lambdacontext.FunctionName}
+	for _, name := range slices.Sorted(maps.Keys(collector.values)) {
+		directive.Metrics = append(directive.Metrics, emfMetric{name, collector.units[name]})
+		document[name] = collector.values[name]
+	}
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		document["RequestId"] = lc.AwsRequestID
+	}
+	document["_aws"] = emfMetadata{Timestamp: timestamp.UnixMilli(), CloudWatchMetrics: []emfDirective{directive}}
+
+	line, err := json.Marshal(document)
+	if err != nil {
+		log.Warn().Err(err).Msg("Error encoding metrics")
+		return
+	}
+	if _, err := MetricsOutput.Write(append(line, '\n')); err != nil {
+		log.Warn().Err(err).Msg("Error writing metrics")
+	}
+}
@@ -0,0 +1,91 @@
+package lambdaruntime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/oslokommune/common-lib-go/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChain_AppliesMiddlewareInOrder(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware[string, string] {
+		return func(next Handler[string, string]) Handler[string, string] {
+			return func(ctx context.Context, event string) (string, error) {
+				calls = append(calls, name)
+				return next(ctx, event)
+			}
+		}
+	}
+
+	handler := Chain(func(ctx context.Context, event string) (string, error) {
+		calls = append(calls, "handler")
+		return event, nil
+	}, trace("first"), trace("second"))
+
+	response, err := handler(context.Background(), "event")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "event", response)
+	assert.Equal(t, []string{"first", "second", "handler"}, calls)
+}
+
+func TestRecovery_ReturnsPanicAsError(t *testing.T) {
+	handler := Chain(func(ctx context.Context, event string) (string, error) {
+		panic("boom")
+	}, Recovery)
+
+	response, err := handler(context.Background(), "event")
+
+	assert.Equal(t, "", response)
+	var stacktrace logging.StackTrace
+	assert.True(t, errors.As(err, &stacktrace))
+	assert.Equal(t, "boom", stacktrace.Reason)
+}
+
+func TestTimeoutHeadroom_MovesDeadline(t *testing.T) {
+	deadline := time.Now().Add(time.Minute)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	handler := Chain(func(ctx context.Context, event string) (time.Time, error) {
+		d, _ := ctx.Deadline()
+		return d, nil
+	}, TimeoutHeadroom[string, time.Time](5*time.Second))
+
+	d, _ := handler(ctx, "event")
+
+	assert.Equal(t, deadline.Add(-5*time.Second), d)
+}
+
+func TestMetrics_WritesEmbeddedMetricFormat(t *testing.T) {
+	var buf bytes.Buffer
+	output := MetricsOutput
+	MetricsOutput = &buf
+	defer func() { MetricsOutput = output }()
+
+	handler := Chain(func(ctx context.Context, event string) (string, error) {
+		AddMetric(ctx, "Orders", 2, "Count")
+		return "", errors.New("failed")
+	}, Metrics[string, string]("my-service"))
+
+	ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "request-1"})
+	_, _ = handler(ctx, "event")
+
+	var document map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &document))
+	assert.Equal(t, 2.0, document["Orders"])
+	assert.Equal(t, 1.0, document["Errors"])
+	assert.Equal(t, 1.0, document["Invocations"])
+	assert.Equal(t, "request-1", document["RequestId"])
+
+	directive := document["_aws"].(map[string]any)["CloudWatchMetrics"].([]any)[0].(map[string]any)
+	assert.Equal(t, "my-service", directive["Namespace"])
+	assert.Contains(t, directive["Metrics"], map[string]any{"Name": "Orders", "Unit": "Count"})
+}
@@ -0,0 +1,179 @@
+package lambdaruntime
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/rs/zerolog/log"
+	lambdadetector "go.opentelemetry.io/contrib/detectors/aws/lambda"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-lambda-go/otellambda"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-lambda-go/otellambda/xrayconfig"
+	"go.opentelemetry.io/contrib/propagators/aws/xray"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/oslokommune/common-lib-go/aws/lambdaruntime"
+
+// Middleware configuring OpenTelemetry for tracing that integrates with AWS X-Ray, like `ginruntime.WithXRayTracing`,
+// and tracing each invocation.
+func XRayTracing[T any, R any](ctx context.Context) Middleware[T, R] {
+	tp, err := xrayconfig.NewTracerProvider(ctx)
+	if err != nil {
+		log.Panic().Err(err).Msg("Error creating trace provider")
+	}
+	return Tracing[T, R](tp, xray.Propagator{}, xrayconfig.WithEventToCarrier())
+}
+
+// Middleware configuring OpenTelemetry with `tp` and `propagator`, like `ginruntime.WithTracing`, and tracing each invocation.
+// Spans are flushed at the end of each invocation, since the execution environment may be frozen afterwards,
+// and the tracer provider is shut down with the execution environment.
+//
+// Usage with an OTLP collector, e.g. the ADOT Lambda layer, and W3C trace context:
+// ```go
+// tp, err := lambdaruntime.NewOTLPTracerProvider(ctx)
+// lambdaruntime.StartHandler(handle, lambdaruntime.Tracing[Event, Response](tp, lambdaruntime.W3CPropagator()))
+// ```
+func Tracing[T any, R any](tp *sdktrace.TracerProvider, propagator propagation.TextMapPropagator, options ...otellambda.Option) Middleware[T, R] {
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagator)
+	OnShutdown(func() {
+		if err := tp.Shutdown(context.Background()); err != nil {
+			log.Error().Err(err).Msg("Error shutting down tracer provider")
+		}
+	})
+
+	options = append([]otellambda.Option{
+		otellambda.WithTracerProvider(tp),
+		otellambda.WithFlusher(tp),
+		otellambda.WithPropagator(propagator),
+	}, options...)
+
+	return func(next Handler[T, R]) Handler[T, R] {
+		handler := func(ctx context.Context, event T) (R, error) {
+			return next(ctx, event)
+		}
+		instrumented := otellambda.InstrumentHandler(handler, options...).(func(context.Context, any) (any, error))
+
+		return func(ctx context.Context, event T) (R, error) {
+			response, err := instrumented(ctx, event)
+			r, _ := response.(R)
+			return r, err
+		}
+	}
+}
+
+// W3C trace context and baggage, used by most tracing vendors including Datadog.
+func W3CPropagator() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+}
+
+// Creates a tracer provider exporting spans with OTLP over gRPC, to `localhost:4317` unless configured otherwise with `options`
+// or the `OTEL_EXPORTER_OTLP_ENDPOINT` environment variable. The resource describes the Lambda function,
+// and the service name is read from `OTEL_SERVICE_NAME`.
+func NewOTLPTracerProvider(ctx context.Context, options ...otlptracegrpc.Option) (*sdktrace.TracerProvider, error) {
+	exporter, err := otlptracegrpc.New(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	detected, err := lambdadetector.NewResourceDetector().Detect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res, err := resource.Merge(resource.Default(), detected)
+	if err != nil {
+		return nil, err
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	), nil
+}
+
+// Creates a tracer provider exporting spans to the OTLP receiver of the Datadog Lambda extension.
+// The receiver must be enabled with `DD_OTLP_CONFIG_RECEIVER_PROTOCOLS_GRPC_ENDPOINT=localhost:4317`.
+// Use it together with `W3CPropagator`.
+func NewDatadogTracerProvider(ctx context.Context) (*sdktrace.TracerProvider, error) {
+	return NewOTLPTracerProvider(ctx, otlptracegrpc.WithInsecure(), otlptracegrpc.WithEndpoint("localhost:4317"))
+}
+
+// Starts a consumer span for a message carrying trace context from its producer in `carrier`.
+// The span continues the producer's trace with a link to the invocation span, or is a child of the invocation span if the message has no trace context.
+func startMessageSpan(ctx context.Context, name string, carrier propagation.TextMapCarrier, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	options := []trace.SpanStartOption{trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(attributes...)}
+
+	producer := otel.GetTextMapPropagator().Extract(ctx, carrier)
+	if remote := trace.SpanContextFromContext(producer); remote.IsValid() && remote.IsRemote() {
+		if invocation := trace.SpanContextFromContext(ctx); invocation.IsValid() {
+			options = append(options, trace.WithLinks(trace.Link{SpanContext: invocation}))
+		}
+		ctx = producer
+	}
+
+	return otel.Tracer(tracerName).Start(ctx, name, options...)
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Trace context from the string message attributes, e.g. `traceparent`, and the X-Ray trace header set by SQS.
+func sqsCarrier(record events.SQSMessage) propagation.MapCarrier {
+	carrier := propagation.MapCarrier{}
+	for name, attribute := range record.MessageAttributes {
+		if attribute.StringValue != nil {
+			carrier[name] = *attribute.StringValue
+		}
+	}
+	if header := record.Attributes["AWSTraceHeader"]; header != "" {
+		carrier["X-Amzn-Trace-Id"] = header
+	}
+	return carrier
+}
+
+// Trace context from the string message attributes, e.g. `traceparent`.
+func snsCarrier(record events.SNSEntity) propagation.MapCarrier {
+	carrier := propagation.MapCarrier{}
+	for name, value := range record.MessageAttributes {
+		if attribute, ok := value.(map[string]any); ok {
+			if s, ok := attribute["Value"].(string); ok {
+				carrier[name] = s
+			}
+		}
+	}
+	return carrier
+}
+
+// Trace context from top level string fields in the event detail, e.g. `traceparent`, since EventBridge has no message attributes.
+func eventBridgeCarrier(detail json.RawMessage) propagation.MapCarrier {
+	carrier := propagation.MapCarrier{}
+	var fields map[string]any
+	if err := json.Unmarshal(detail, &fields); err != nil {
+		return carrier
+	}
+	for name, value := range fields {
+		if s, ok := value.(string); ok {
+			carrier[name] = s
+		}
+	}
+	return carrier
+}
+
+// Last part of an ARN, e.g. the queue name of an SQS queue ARN.
+func resourceName(arn string) string {
+	return arn[strings.LastIndex(arn, ":")+1:]
+}
@@ -0,0 +1,75 @@
+package lambdaruntime
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	producerTraceId = "4bf92f3577b34da6a3ce929d0e0e4736"
+	traceparent     = "00-" + producerTraceId + "-00f067aa0ba902b7-01"
+)
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	propagator := otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(W3CPropagator())
+	t.Cleanup(func() { otel.SetTextMapPropagator(propagator) })
+	return recorder
+}
+
+func TestSQSHandler_ContinuesTraceFromMessageAttributes(t *testing.T) {
+	recorder := recordSpans(t)
+	handler := SQSHandler(func(ctx context.Context, body string) error { return nil })
+
+	value := traceparent
+	_, _ = handler(context.Background(), events.SQSEvent{Records: []events.SQSMessage{{
+		MessageId:         "1",
+		EventSourceARN:    "arn:aws:sqs:eu-west-1:123456789012:orders",
+		MessageAttributes: map[string]events.SQSMessageAttribute{"traceparent": {StringValue: &value, DataType: "String"}},
+	}}})
+
+	spans := recorder.Ended()
+	assert.Equal(t, 1, len(spans))
+	assert.Equal(t, "orders process", spans[0].Name())
+	assert.Equal(t, producerTraceId, spans[0].SpanContext().TraceID().String())
+}
+
+func TestEventBridgeHandler_ContinuesTraceFromDetail(t *testing.T) {
+	recorder := recordSpans(t)
+	handler := EventBridgeHandler(func(ctx context.Context, event EventBridgeEvent[order]) error { return nil })
+
+	detail, _ := json.Marshal(map[string]any{"id": "a", "traceparent": traceparent})
+	_ = handler(context.Background(), events.EventBridgeEvent{ID: "1", DetailType: "OrderCreated", Detail: detail})
+
+	spans := recorder.Ended()
+	assert.Equal(t, 1, len(spans))
+	assert.Equal(t, "OrderCreated process", spans[0].Name())
+	assert.Equal(t, producerTraceId, spans[0].SpanContext().TraceID().String())
+}
+
+func TestTracing_TracesInvocation(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	propagator := otel.GetTextMapPropagator()
+	t.Cleanup(func() { otel.SetTextMapPropagator(propagator) })
+
+	handler := Chain(func(ctx context.Context, event order) (string, error) {
+		return event.Id, nil
+	}, Tracing[order, string](tp, W3CPropagator()))
+
+	response, err := handler(context.Background(), order{Id: "a"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "a", response)
+	assert.Equal(t, 1, len(recorder.Ended()))
+}
@@ -9,8 +9,25 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// Applied to query parameters before they are logged at DEBUG level.
+// Defaults to masking all values, since parameters often contain personal data or secrets.
+// Set it to `LogQueryParamsVerbatim` to log the values while debugging locally.
+var QueryParamsFilter func(params []interface{}) []interface{} = MaskQueryParams
+
+func MaskQueryParams(params []interface{}) []interface{} {
+	masked := make([]interface{}, len(params))
+	for i := range params {
+		masked[i] = "***"
+	}
+	return masked
+}
+
+func LogQueryParamsVerbatim(params []interface{}) []interface{} {
+	return params
+}
+
 func PerformSelect(dbConn *sqlx.DB, result interface{}, query string, params ...interface{}) (empty bool, err error) {
-	log.Debug().Msgf("Execution query: %s, %v", query, params)
+	log.Debug().Msgf("Execution query: %s, %v", query, QueryParamsFilter(params))
 
 	err = dbConn.Select(result, query, params...)
 	if err != nil {
@@ -25,7 +42,7 @@ func PerformSelect(dbConn *sqlx.DB, result interface{}, query string, params ...
 }
 
 func PerformGet(dbConn *sqlx.DB, result interface{}, query string, params ...interface{}) (empty bool, err error) {
-	log.Debug().Msgf("Execution query: %s, %v", query, params)
+	log.Debug().Msgf("Execution query: %s, %v", query, QueryParamsFilter(params))
 
 	err = dbConn.Get(result, query, params...)
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 
+	"github.com/oslokommune/common-lib-go/logging"
 	"github.com/rs/zerolog/log"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
@@ -14,6 +15,8 @@ type grpcRuntime struct {
 }
 
 func NewServer(enableTracing bool, opt ...grpc.ServerOption) *grpcRuntime {
+	logging.Configure(logging.WithLevelSignal())
+
 	if enableTracing {
 		opt = append(opt, grpc.StatsHandler(otelgrpc.NewServerHandler()))
 	}
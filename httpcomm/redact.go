@@ -0,0 +1,10 @@
+package httpcomm
+
+import (
+	"github.com/oslokommune/common-lib-go/logging"
+)
+
+// Applied to request and response dumps before they are logged at DEBUG and TRACE level.
+// Defaults to masking `logging.DefaultRedactedHeaders`, `logging.DefaultRedactedFields` in JSON bodies and the other defaults of `logging.Redactor`.
+// Replace it to mask more, e.g. with the `RedactDump` method of a `logging.Redactor` with more fields.
+var DumpFilter func(dump []byte) []byte = logging.NewRedactor().RedactDump
@@ -0,0 +1,15 @@
+package httpcomm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpFilter_MasksHeadersAndBodyFields(t *testing.T) {
+	dump := "POST /login HTTP/1.1\r\nHost: example.com\r\nAuthorization: Bearer secret-token\r\nAccept: application/json\r\n\r\n{\"username\":\"ola\",\"password\":\"hunter2\"}"
+
+	redacted := string(DumpFilter([]byte(dump)))
+
+	assert.Equal(t, "POST /login HTTP/1.1\r\nHost: example.com\r\nAuthorization: ***\r\nAccept: application/json\r\n\r\n{\"username\":\"ola\",\"password\":\"***\"}", redacted)
+}
@@ -42,7 +42,7 @@ func Call(ctx context.Context, httpClient HttpDoer, httpRequest HTTPRequest) (*H
 	if log.Debug().Enabled() {
 		reqDump, err := httputil.DumpRequestOut(req, true)
 		if err == nil {
-			log.Debug().Msg(string(reqDump))
+			log.Debug().Msg(string(DumpFilter(reqDump)))
 		}
 	}
 
@@ -58,7 +58,7 @@ func Call(ctx context.Context, httpClient HttpDoer, httpRequest HTTPRequest) (*H
 	if log.Trace().Enabled() {
 		resDump, err := httputil.DumpResponse(resp, true)
 		if err == nil {
-			log.Trace().Msg(string(resDump))
+			log.Trace().Msg(string(DumpFilter(resDump)))
 		}
 	}
 
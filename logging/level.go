@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Parses a log level name, ignoring case. Accepts WARNING as an alias for WARN and OFF for DISABLED.
+func ParseLevel(level string) (zerolog.Level, error) {
+	switch strings.ToUpper(strings.TrimSpace(level)) {
+	case "TRACE":
+		return zerolog.TraceLevel, nil
+	case "DEBUG":
+		return zerolog.DebugLevel, nil
+	case "INFO":
+		return zerolog.InfoLevel, nil
+	case "WARN", "WARNING":
+		return zerolog.WarnLevel, nil
+	case "ERROR":
+		return zerolog.ErrorLevel, nil
+	case "FATAL":
+		return zerolog.FatalLevel, nil
+	case "PANIC":
+		return zerolog.PanicLevel, nil
+	case "DISABLED", "OFF":
+		return zerolog.Disabled, nil
+	default:
+		return zerolog.NoLevel, fmt.Errorf("unknown log level %q", level)
+	}
+}
+
+// Changes the log level of all loggers at runtime.
+func SetLevel(level zerolog.Level) {
+	zerolog.SetGlobalLevel(level)
+}
+
+func Level() zerolog.Level {
+	return zerolog.GlobalLevel()
+}
+
+type levelBody struct {
+	Level string `json:"level"`
+}
+
+// HTTP handler for reading and changing the log level at runtime.
+//
+// GET responds with the current level, e.g. `{"level":"INFO"}`.
+// PUT and POST change the level, given either as a JSON body `{"level":"DEBUG"}` or a `level` query parameter.
+//
+// The handler does no authorization, so protect it with middleware or only expose it on an internal port.
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			name := r.URL.Query().Get("level")
+			if name == "" {
+				var body levelBody
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					http.Error(w, "expected a JSON body with a level", http.StatusBadRequest)
+					return
+				}
+				name = body.Level
+			}
+
+			level, err := ParseLevel(name)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			log.Info().Msgf("Changing log level from %s to %s", levelName(Level()), levelName(level))
+			SetLevel(level)
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(levelBody{Level: levelName(Level())})
+	})
+}
+
+func levelName(level zerolog.Level) string {
+	return strings.ToUpper(level.String())
+}
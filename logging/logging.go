@@ -0,0 +1,221 @@
+package logging
+
+import (
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog/pkgerrors"
+)
+
+type Format int
+
+const (
+	// JSON when running as a Lambda, console otherwise. Can be overridden with the `LOG_FORMAT` environment variable,
+	// e.g. `LOG_FORMAT=json` for ECS tasks.
+	FormatAuto Format = iota
+	FormatJSON
+	FormatConsole
+)
+
+// Names of the standard fields in the log events.
+type FieldNames struct {
+	Timestamp  string
+	Level      string
+	Message    string
+	ErrorStack string
+}
+
+// Field names corresponding with the logstash format.
+var DefaultFieldNames = FieldNames{
+	Timestamp:  "@timestamp",
+	Level:      "level",
+	Message:    "message",
+	ErrorStack: "stack_trace",
+}
+
+type config struct {
+	level          *zerolog.Level
+	format         Format
+	output         io.Writer
+	fieldNames     FieldNames
+	staticFields   map[string]string
+	stackMarshaler func(err error) any
+	caller         bool
+	levelSignal    bool
+	redactor       *Redactor
+}
+
+type Option func(*config)
+
+// Sets the log level. Defaults to the `LOG_LEVEL` environment variable, or INFO if it isn't set.
+func WithLevel(level zerolog.Level) Option {
+	return func(c *config) {
+		c.level = &level
+	}
+}
+
+func WithFormat(format Format) Option {
+	return func(c *config) {
+		c.format = format
+	}
+}
+
+// Sets where log events are written. Defaults to stderr.
+func WithOutput(output io.Writer) Option {
+	return func(c *config) {
+		c.output = output
+	}
+}
+
+func WithFieldNames(fieldNames FieldNames) Option {
+	return func(c *config) {
+		c.fieldNames = fieldNames
+	}
+}
+
+// Adds a field with a fixed value to all log events.
+func WithStaticField(key string, value string) Option {
+	return func(c *config) {
+		c.staticFields[key] = value
+	}
+}
+
+// Adds a `service_version` field. Defaults to the `APP_VERSION` environment variable.
+func WithServiceVersion(version string) Option {
+	return WithStaticField("service_version", version)
+}
+
+// Adds an `environment` field. Defaults to the `ENVIRONMENT` environment variable.
+func WithEnvironment(environment string) Option {
+	return WithStaticField("environment", environment)
+}
+
+// Sets the function extracting stack traces from errors logged with `Stack()`.
+// Defaults to extracting `github.com/pkg/errors` stack traces.
+func WithStackMarshaler(marshaler func(err error) any) Option {
+	return func(c *config) {
+		c.stackMarshaler = marshaler
+	}
+}
+
+// Includes the file and line of the log statement. Enabled by default.
+func WithCaller(enabled bool) Option {
+	return func(c *config) {
+		c.caller = enabled
+	}
+}
+
+// Masks sensitive values in all log events before they are written, see `Redactor`.
+// Defaults to a `Redactor` with the default settings. Pass nil to disable redaction.
+func WithRedactor(redactor *Redactor) Option {
+	return func(c *config) {
+		c.redactor = redactor
+	}
+}
+
+// Toggles between the configured log level and DEBUG when the process receives SIGUSR1.
+// Not supported on Windows.
+func WithLevelSignal() Option {
+	return func(c *config) {
+		c.levelSignal = true
+	}
+}
+
+// Configures zerolog and the global logger `log.Logger`.
+//
+// Usage:
+// ```go
+// logging.Configure(logging.WithServiceVersion(version), logging.WithStaticField("team", "bymiljø"))
+// ```
+func Configure(options ...Option) zerolog.Logger {
+	c := &config{
+		format:         FormatAuto,
+		output:         os.Stderr,
+		fieldNames:     DefaultFieldNames,
+		staticFields:   defaultStaticFields(),
+		stackMarshaler: pkgerrors.MarshalStack,
+		caller:         true,
+		redactor:       NewRedactor(),
+	}
+	for _, option := range options {
+		option(c)
+	}
+
+	level := zerolog.InfoLevel
+	if c.level != nil {
+		level = *c.level
+	} else if l := os.Getenv("LOG_LEVEL"); l != "" {
+		parsed, err := ParseLevel(l)
+		if err == nil {
+			level = parsed
+		}
+	}
+	SetLevel(level)
+
+	zerolog.TimeFieldFormat = time.RFC3339
+	zerolog.ErrorStackMarshaler = c.stackMarshaler
+	zerolog.TimestampFieldName = c.fieldNames.Timestamp
+	zerolog.LevelFieldName = c.fieldNames.Level
+	zerolog.MessageFieldName = c.fieldNames.Message
+	zerolog.ErrorStackFieldName = c.fieldNames.ErrorStack
+
+	zerolog.LevelFieldMarshalFunc = func(l zerolog.Level) string {
+		return strings.ToUpper(l.String())
+	}
+
+	output := c.output
+	if resolveFormat(c.format) == FormatConsole {
+		output = zerolog.ConsoleWriter{Out: output}
+	}
+	if c.redactor != nil {
+		output = c.redactor.Writer(output)
+	}
+
+	context := zerolog.New(output).With().Timestamp()
+	for key, value := range c.staticFields {
+		context = context.Str(key, value)
+	}
+	if c.caller {
+		context = context.Caller()
+	}
+	log.Logger = context.Logger()
+
+	if c.levelSignal {
+		toggleLevelOnSignal(level)
+	}
+
+	return log.Logger
+}
+
+func defaultStaticFields() map[string]string {
+	fields := map[string]string{"app_label": os.Getenv("APP_LABEL")}
+	if version := os.Getenv("APP_VERSION"); version != "" {
+		fields["service_version"] = version
+	}
+	if environment := os.Getenv("ENVIRONMENT"); environment != "" {
+		fields["environment"] = environment
+	}
+	return fields
+}
+
+func resolveFormat(format Format) Format {
+	if format != FormatAuto {
+		return format
+	}
+
+	switch strings.ToLower(os.Getenv("LOG_FORMAT")) {
+	case "json":
+		return FormatJSON
+	case "console":
+		return FormatConsole
+	}
+
+	if os.Getenv("AWS_LAMBDA_FUNCTION_NAME") != "" {
+		return FormatJSON
+	}
+	return FormatConsole
+}
@@ -0,0 +1,106 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLevel_IgnoresCaseAndAcceptsAliases(t *testing.T) {
+	cases := map[string]zerolog.Level{
+		"debug":    zerolog.DebugLevel,
+		"INFO":     zerolog.InfoLevel,
+		"Warn":     zerolog.WarnLevel,
+		"WARNING":  zerolog.WarnLevel,
+		"fatal":    zerolog.FatalLevel,
+		"off":      zerolog.Disabled,
+		" TRACE  ": zerolog.TraceLevel,
+	}
+
+	for name, expected := range cases {
+		level, err := ParseLevel(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, level, name)
+	}
+
+	_, err := ParseLevel("verbose")
+	assert.Error(t, err)
+}
+
+func TestConfigure_WritesJsonWithConfiguredFields(t *testing.T) {
+	var buf bytes.Buffer
+	Configure(
+		WithOutput(&buf),
+		WithFormat(FormatJSON),
+		WithLevel(zerolog.InfoLevel),
+		WithServiceVersion("1.2.3"),
+		WithEnvironment("test"),
+		WithStaticField("app_label", "my-app"),
+	)
+
+	log.Info().Msg("hello")
+	log.Debug().Msg("hidden")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, 1, len(lines))
+
+	var event map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &event))
+	assert.Equal(t, "hello", event["message"])
+	assert.Equal(t, "INFO", event["level"])
+	assert.Equal(t, "my-app", event["app_label"])
+	assert.Equal(t, "1.2.3", event["service_version"])
+	assert.Equal(t, "test", event["environment"])
+	assert.Contains(t, event, "@timestamp")
+	assert.Contains(t, event, "caller")
+}
+
+func TestResolveFormat_JsonOnlyInLambdaByDefault(t *testing.T) {
+	t.Setenv("LOG_FORMAT", "")
+	t.Setenv("AWS_LAMBDA_FUNCTION_NAME", "")
+	t.Setenv("ECS_CONTAINER_METADATA_URI_V4", "http://169.254.170.2/v4/task")
+	assert.Equal(t, FormatConsole, resolveFormat(FormatAuto))
+
+	t.Setenv("LOG_FORMAT", "json")
+	assert.Equal(t, FormatJSON, resolveFormat(FormatAuto))
+
+	t.Setenv("LOG_FORMAT", "")
+	t.Setenv("AWS_LAMBDA_FUNCTION_NAME", "my-function")
+	assert.Equal(t, FormatJSON, resolveFormat(FormatAuto))
+	assert.Equal(t, FormatConsole, resolveFormat(FormatConsole))
+}
+
+func TestConfigure_ReadsLevelFromEnvironment(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "warn")
+
+	Configure(WithOutput(&bytes.Buffer{}))
+
+	assert.Equal(t, zerolog.WarnLevel, Level())
+}
+
+func TestLevelHandler_ChangesLevel(t *testing.T) {
+	SetLevel(zerolog.InfoLevel)
+	handler := LevelHandler()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/loglevel", strings.NewReader(`{"level":"debug"}`))
+	handler.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.JSONEq(t, `{"level":"DEBUG"}`, res.Body.String())
+	assert.Equal(t, zerolog.DebugLevel, Level())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", "/loglevel?level=loud", nil)
+	handler.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+	assert.Equal(t, zerolog.DebugLevel, Level())
+}
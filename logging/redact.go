@@ -0,0 +1,358 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+const Mask = "***"
+
+// Headers whose values are always masked.
+var DefaultRedactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Amz-Security-Token",
+	"X-Aws-Parameters-Secrets-Token",
+}
+
+// JSON fields whose values are always masked, at any depth.
+var DefaultRedactedFields = []string{
+	"password",
+	"passwd",
+	"secret",
+	"client_secret",
+	"token",
+	"access_token",
+	"refresh_token",
+	"id_token",
+	"api_key",
+	"fodselsnummer",
+	"fnr",
+	"ssn",
+}
+
+var (
+	bearerTokenPattern   = regexp.MustCompile(`(?i)(bearer\s+)[a-z0-9\-._~+/]+=*`)
+	jwtPattern           = regexp.MustCompile(`eyJ[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]*`)
+	passwordParamPattern = regexp.MustCompile(`(?i)((?:password|passwd|pwd|secret|token)=)[^&\s"]+`)
+	fodselsnummerPattern = regexp.MustCompile(`\b\d{6}\s?\d{5}\b`)
+)
+
+type pattern struct {
+	regexp  *regexp.Regexp
+	replace func(match string) string
+}
+
+// Masks sensitive values in log events, HTTP headers and HTTP dumps.
+//
+// By default it masks `DefaultRedactedHeaders`, `DefaultRedactedFields`, bearer tokens, JWTs,
+// password query parameters and Norwegian national identity numbers (fødselsnummer and D-nummer).
+type Redactor struct {
+	headers  map[string]bool
+	fields   [][]string
+	patterns []pattern
+	// The last name of each field path, lowercased and quoted, to find documents that can't have the fields without decoding them
+	names [][]byte
+}
+
+type RedactOption func(*Redactor)
+
+// Masks the values of headers named `names`, ignoring case.
+func WithRedactedHeaders(names ...string) RedactOption {
+	return func(r *Redactor) {
+		for _, name := range names {
+			r.headers[strings.ToLower(name)] = true
+		}
+	}
+}
+
+// Masks the values of JSON fields matching `paths`, ignoring case.
+// A path is a dot separated list of field names, e.g. `user.address`, matched against the end of the field's full path,
+// so `password` matches password fields at any depth.
+func WithRedactedFields(paths ...string) RedactOption {
+	return func(r *Redactor) {
+		for _, path := range paths {
+			names := strings.Split(strings.ToLower(path), ".")
+			r.fields = append(r.fields, names)
+			r.names = append(r.names, []byte(`"`+names[len(names)-1]+`"`))
+		}
+	}
+}
+
+// Replaces text matching any of `patterns` with `***`.
+func WithRedactedPatterns(patterns ...*regexp.Regexp) RedactOption {
+	return func(r *Redactor) {
+		for _, p := range patterns {
+			r.patterns = append(r.patterns, pattern{p, func(string) string { return Mask }})
+		}
+	}
+}
+
+func NewRedactor(options ...RedactOption) *Redactor {
+	r := &Redactor{headers: map[string]bool{}}
+	WithRedactedHeaders(DefaultRedactedHeaders...)(r)
+	WithRedactedFields(DefaultRedactedFields...)(r)
+	r.patterns = []pattern{
+		{bearerTokenPattern, func(match string) string {
+			return bearerTokenPattern.ReplaceAllString(match, "${1}"+Mask)
+		}},
+		{jwtPattern, func(string) string { return Mask }},
+		{passwordParamPattern, func(match string) string {
+			return passwordParamPattern.ReplaceAllString(match, "${1}"+Mask)
+		}},
+		{fodselsnummerPattern, func(match string) string {
+			if IsFodselsnummer(strings.ReplaceAll(match, " ", "")) {
+				return Mask
+			}
+			return match
+		}},
+	}
+
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// Masks all pattern matches in `s`.
+func (r *Redactor) RedactString(s string) string {
+	for _, p := range r.patterns {
+		s = p.regexp.ReplaceAllStringFunc(s, p.replace)
+	}
+	return s
+}
+
+// Returns a copy of `header` with sensitive values masked.
+func (r *Redactor) RedactHeaders(header http.Header) http.Header {
+	redacted := make(http.Header, len(header))
+	for name, values := range header {
+		if r.headers[strings.ToLower(name)] {
+			redacted[name] = []string{Mask}
+			continue
+		}
+		redacted[name] = make([]string, len(values))
+		for i, value := range values {
+			redacted[name][i] = r.RedactString(value)
+		}
+	}
+	return redacted
+}
+
+// Masks sensitive fields and pattern matches in a JSON document, keeping the order of the fields.
+// Input that isn't valid JSON is treated as text. Input without any of the field names or patterns is returned as is, without decoding it.
+func (r *Redactor) RedactJSON(data []byte) []byte {
+	if !r.mayBeSensitive(data) {
+		return data
+	}
+
+	trimmed := bytes.TrimRight(data, "\n")
+	if !json.Valid(trimmed) {
+		return []byte(r.RedactString(string(data)))
+	}
+
+	var buf bytes.Buffer
+	r.redactValue(&buf, trimmed, nil)
+	buf.Write(data[len(trimmed):])
+	return buf.Bytes()
+}
+
+// Masks sensitive headers and body content in an HTTP dump, e.g. from `httputil.DumpRequestOut`.
+func (r *Redactor) RedactDump(dump []byte) []byte {
+	head, body, found := bytes.Cut(dump, []byte("\r\n\r\n"))
+
+	var buf bytes.Buffer
+	// Split instead of using a bufio.Scanner, which stops at lines longer than its buffer, e.g. large cookies
+	for i, line := range strings.Split(string(head), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if i > 0 {
+			buf.WriteString("\r\n")
+		}
+		if name, _, ok := strings.Cut(line, ":"); ok && i > 0 && r.headers[strings.ToLower(strings.TrimSpace(name))] {
+			line = name + ": " + Mask
+		} else {
+			line = r.RedactString(line)
+		}
+		buf.WriteString(line)
+	}
+
+	if found {
+		buf.WriteString("\r\n\r\n")
+		buf.Write(r.RedactJSON(body))
+	}
+	return buf.Bytes()
+}
+
+// Wraps `w` so log events are redacted before they are written.
+// zerolog hooks can't change fields already added to an event, so redaction is done on the encoded event.
+func (r *Redactor) Writer(w io.Writer) zerolog.LevelWriter {
+	return &redactingWriter{r, w}
+}
+
+type redactingWriter struct {
+	redactor *Redactor
+	w        io.Writer
+}
+
+func (w *redactingWriter) Write(p []byte) (int, error) {
+	if _, err := w.w.Write(w.redactor.RedactJSON(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *redactingWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	lw, ok := w.w.(zerolog.LevelWriter)
+	if !ok {
+		return w.Write(p)
+	}
+	if _, err := lw.WriteLevel(level, w.redactor.RedactJSON(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Reports whether `data` contains one of the redacted field names, ignoring case, or matches one of the patterns.
+// Cheaper than decoding and encoding every log event, most of which contain neither.
+func (r *Redactor) mayBeSensitive(data []byte) bool {
+	lower := bytes.ToLower(data)
+	for _, name := range r.names {
+		if bytes.Contains(lower, name) {
+			return true
+		}
+	}
+	for _, p := range r.patterns {
+		if p.regexp.Match(data) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Redactor) fieldRedacted(path []string) bool {
+	for _, field := range r.fields {
+		if len(field) > len(path) {
+			continue
+		}
+		matches := true
+		offset := len(path) - len(field)
+		for i, name := range field {
+			if strings.ToLower(path[offset+i]) != name {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Redactor) redactValue(buf *bytes.Buffer, raw []byte, path []string) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return
+	}
+
+	switch raw[0] {
+	case '{':
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		_, _ = dec.Token()
+		buf.WriteByte('{')
+		for i := 0; dec.More(); i++ {
+			token, _ := dec.Token()
+			key, _ := token.(string)
+			var value json.RawMessage
+			_ = dec.Decode(&value)
+
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJSONString(buf, key)
+			buf.WriteByte(':')
+
+			fieldPath := append(path[:len(path):len(path)], key)
+			if r.fieldRedacted(fieldPath) {
+				writeJSONString(buf, Mask)
+			} else {
+				r.redactValue(buf, value, fieldPath)
+			}
+		}
+		buf.WriteByte('}')
+	case '[':
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		_, _ = dec.Token()
+		buf.WriteByte('[')
+		for i := 0; dec.More(); i++ {
+			var value json.RawMessage
+			_ = dec.Decode(&value)
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			r.redactValue(buf, value, path)
+		}
+		buf.WriteByte(']')
+	case '"':
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			buf.Write(raw)
+			return
+		}
+		if redacted := r.RedactString(s); redacted != s {
+			writeJSONString(buf, redacted)
+			return
+		}
+		buf.Write(raw)
+	default:
+		buf.Write(raw)
+	}
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	// Encode adds a newline
+	buf.Truncate(buf.Len() - 1)
+}
+
+// Reports whether `s` is a valid Norwegian national identity number (fødselsnummer or D-nummer),
+// by checking its two control digits.
+func IsFodselsnummer(s string) bool {
+	if len(s) != 11 {
+		return false
+	}
+
+	digits := make([]int, 11)
+	for i, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+		digits[i] = int(c - '0')
+	}
+
+	control := func(weights []int) int {
+		sum := 0
+		for i, w := range weights {
+			sum += w * digits[i]
+		}
+		k := 11 - sum%11
+		if k == 11 {
+			return 0
+		}
+		return k
+	}
+
+	k1 := control([]int{3, 7, 6, 1, 8, 9, 4, 5, 2})
+	k2 := control([]int{5, 4, 3, 2, 7, 6, 5, 4, 3, 2})
+	return k1 == digits[9] && k2 == digits[10]
+}
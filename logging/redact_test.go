@@ -0,0 +1,99 @@
+package logging
+
+import (
+	"bytes"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// Synthetic fødselsnummer with valid control digits
+const fodselsnummer = "01010012356"
+
+func TestIsFodselsnummer_ChecksControlDigits(t *testing.T) {
+	assert.True(t, IsFodselsnummer(fodselsnummer))
+	assert.False(t, IsFodselsnummer("01010012357"))
+	assert.False(t, IsFodselsnummer("0101001235"))
+}
+
+func TestRedactString_MasksTokensAndIdentityNumbers(t *testing.T) {
+	r := NewRedactor()
+
+	assert.Equal(t, "Authorization: Bearer ***", r.RedactString("Authorization: Bearer abc.def-ghi"))
+	assert.Equal(t, "https://example.com?user=a&password=***", r.RedactString("https://example.com?user=a&password=hunter2"))
+	assert.Equal(t, "person *** was updated", r.RedactString("person "+fodselsnummer+" was updated"))
+	assert.Equal(t, "order 12345678901 was updated", r.RedactString("order 12345678901 was updated"))
+}
+
+func TestRedactJSON_MasksFieldsKeepingOrder(t *testing.T) {
+	r := NewRedactor(WithRedactedFields("user.address"))
+
+	redacted := r.RedactJSON([]byte(`{"b":1,"password":"hunter2","user":{"Address":"Storgata 1","name":"Ola"},"list":[{"token":"x"}],"a":"` + fodselsnummer + `"}` + "\n"))
+
+	assert.Equal(t, `{"b":1,"password":"***","user":{"Address":"***","name":"Ola"},"list":[{"token":"***"}],"a":"***"}`+"\n", string(redacted))
+}
+
+func TestRedactHeaders_MasksSensitiveHeaders(t *testing.T) {
+	r := NewRedactor(WithRedactedHeaders("X-Custom-Secret"))
+
+	redacted := r.RedactHeaders(http.Header{
+		"Authorization":   {"Bearer abc"},
+		"X-Custom-Secret": {"s3cr3t"},
+		"Accept":          {"application/json"},
+	})
+
+	assert.Equal(t, Mask, redacted.Get("Authorization"))
+	assert.Equal(t, Mask, redacted.Get("X-Custom-Secret"))
+	assert.Equal(t, "application/json", redacted.Get("Accept"))
+}
+
+func TestRedactDump_MasksHeadersAndBody(t *testing.T) {
+	r := NewRedactor()
+	dump := "POST /login HTTP/1.1\r\nHost: example.com\r\nAuthorization: Basic dXNlcjpwYXNz\r\n\r\n{\"username\":\"ola\",\"password\":\"hunter2\"}"
+
+	redacted := string(r.RedactDump([]byte(dump)))
+
+	assert.Equal(t, "POST /login HTTP/1.1\r\nHost: example.com\r\nAuthorization: ***\r\n\r\n{\"username\":\"ola\",\"password\":\"***\"}", redacted)
+}
+
+func TestRedactDump_HandlesLongHeaderLines(t *testing.T) {
+	r := NewRedactor()
+	cookie := strings.Repeat("c", 100*1024)
+	dump := "GET / HTTP/1.1\r\nCookie: " + cookie + "\r\nX-Long: " + cookie + "\r\nAccept: */*\r\n\r\n"
+
+	redacted := string(r.RedactDump([]byte(dump)))
+
+	assert.Equal(t, "GET / HTTP/1.1\r\nCookie: ***\r\nX-Long: "+cookie+"\r\nAccept: */*\r\n\r\n", redacted)
+}
+
+func TestRedactJSON_PassesThroughDocumentsWithoutSensitiveContent(t *testing.T) {
+	r := NewRedactor()
+
+	// Redacted documents are encoded again without the spaces
+	plain := []byte(`{"level": "info", "message": "Created order 42"}` + "\n")
+	assert.Equal(t, string(plain), string(r.RedactJSON(plain)))
+	assert.Equal(t, `{"level":"info","Password":"***"}`, string(r.RedactJSON([]byte(`{"level": "info", "Password": "hunter2"}`))))
+	assert.Equal(t, `{"level":"info","message":"Bearer ***"}`, string(r.RedactJSON([]byte(`{"level": "info", "message": "Bearer abc"}`))))
+}
+
+func TestConfigure_WithRedactor_MasksLogEvents(t *testing.T) {
+	var buf bytes.Buffer
+	Configure(
+		WithOutput(&buf),
+		WithFormat(FormatJSON),
+		WithLevel(zerolog.InfoLevel),
+		WithRedactor(NewRedactor(WithRedactedPatterns(regexp.MustCompile(`kunde-\d+`)))),
+	)
+
+	log.Info().Str("password", "hunter2").Msgf("Looked up kunde-42 with fnr %s", fodselsnummer)
+
+	output := buf.String()
+	assert.False(t, strings.Contains(output, "hunter2"))
+	assert.False(t, strings.Contains(output, fodselsnummer))
+	assert.Contains(t, output, `"message":"Looked up *** with fnr ***"`)
+}
@@ -0,0 +1,34 @@
+//go:build !windows
+
+package logging
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+var signalOnce sync.Once
+
+// Toggles between `level` and DEBUG each time SIGUSR1 is received.
+func toggleLevelOnSignal(level zerolog.Level) {
+	signalOnce.Do(func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGUSR1)
+
+		go func() {
+			for range signals {
+				next := zerolog.DebugLevel
+				if Level() == zerolog.DebugLevel {
+					next = level
+				}
+				log.Info().Msgf("Received SIGUSR1, changing log level from %s to %s", levelName(Level()), levelName(next))
+				SetLevel(next)
+			}
+		}()
+	})
+}
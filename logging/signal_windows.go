@@ -0,0 +1,10 @@
+package logging
+
+import (
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func toggleLevelOnSignal(level zerolog.Level) {
+	log.Warn().Msg("Changing the log level with SIGUSR1 is not supported on Windows")
+}
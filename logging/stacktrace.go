@@ -0,0 +1,216 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+const maxStackDepth = 64
+
+type GoRoutine = string
+
+type Frame struct {
+	File     string
+	Line     string
+	Function string
+}
+
+type StackTrace struct {
+	GoRoutine string
+	Stack     []Frame
+	Reason    any
+}
+
+// Errors created with `github.com/pkg/errors` carry a stack trace through this interface.
+type stackTracer interface {
+	StackTrace() pkgerrors.StackTrace
+}
+
+// Decides which frames are included when a stack trace is logged by `StackTraceMarshaller`.
+// Set it to nil to include all frames.
+var StackFrameFilter = SkipFramesWithPrefix("runtime.", "testing.", "net/http.", "github.com/gin-gonic/gin.")
+
+// Returns a frame filter excluding frames whose function name starts with one of `prefixes`.
+func SkipFramesWithPrefix(prefixes ...string) func(Frame) bool {
+	return func(frame Frame) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(frame.Function, prefix) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Extracts stack trace from a `StackTrace` error, or an error with a `github.com/pkg/errors` stack trace,
+// anywhere in the chain of wrapped errors, and returns it with `StackFrameFilter` applied.
+// Returns nil for errors without a stack trace.
+//
+// Usage:
+// ```go
+// logging.Configure(logging.WithStackMarshaler(logging.StackTraceMarshaller))
+// goroutine, stack := logging.GetStack()
+// stacktrace := logging.StackTrace{GoRoutine: goroutine, Stack: stack, Reason: r}
+// log.Info().Stack().Err(stacktrace).Msg("An error occurred")
+// ```
+func StackTraceMarshaller(err error) any {
+	var stack []Frame
+
+	var stacktrace StackTrace
+	var tracer stackTracer
+	switch {
+	case errors.As(err, &stacktrace):
+		stack = stacktrace.Stack
+	case errors.As(err, &tracer):
+		pcs := make([]uintptr, len(tracer.StackTrace()))
+		for i, frame := range tracer.StackTrace() {
+			pcs[i] = uintptr(frame)
+		}
+		stack = framesFromPCs(pcs)
+	default:
+		return nil
+	}
+
+	if StackFrameFilter != nil {
+		stack = StackTrace{Stack: stack}.Filter(StackFrameFilter).Stack
+	}
+
+	out := make([]map[string]string, len(stack))
+	for i, frame := range stack {
+		out[i] = map[string]string{
+			"file":     frame.File,
+			"line":     frame.Line,
+			"function": frame.Function,
+		}
+	}
+	return out
+}
+
+func (s StackTrace) Error() string {
+	return fmt.Sprintf("panic in %s: %v", s.GoRoutine, s.Reason)
+}
+
+func (s StackTrace) Skip(n int) StackTrace {
+	if n < 0 {
+		n = 0
+	}
+	if n >= len(s.Stack) {
+		n = len(s.Stack) - 1
+	}
+
+	return StackTrace{
+		GoRoutine: s.GoRoutine,
+		Stack:     s.Stack[n:],
+		Reason:    s.Reason,
+	}
+}
+
+// Returns a copy of the stack trace with only the frames `keep` returns true for.
+func (s StackTrace) Filter(keep func(Frame) bool) StackTrace {
+	stack := make([]Frame, 0, len(s.Stack))
+	for _, frame := range s.Stack {
+		if keep(frame) {
+			stack = append(stack, frame)
+		}
+	}
+
+	return StackTrace{
+		GoRoutine: s.GoRoutine,
+		Stack:     stack,
+		Reason:    s.Reason,
+	}
+}
+
+// Skips the frames of the recovering function and the runtime's panic handling,
+// so the stack starts where the panic was raised.
+func (s StackTrace) SkipFramesAfterPanic() StackTrace {
+	return s.Skip(s.estimateNumberOfInternalFrames())
+}
+
+func (s StackTrace) estimateNumberOfInternalFrames() int {
+	panicFrameIndex := -1
+	for i := len(s.Stack) - 1; i >= 0; i-- {
+		if s.Stack[i].Function == "runtime.gopanic" {
+			panicFrameIndex = i
+			break
+		}
+	}
+	if panicFrameIndex < 0 {
+		return 0
+	}
+
+	// Runtime errors, e.g. nil pointer dereferences, are raised from within the runtime
+	n := panicFrameIndex + 1
+	for n < len(s.Stack) && strings.HasPrefix(s.Stack[n].Function, "runtime.") {
+		n++
+	}
+
+	if n < len(s.Stack) {
+		return n
+	}
+	return 0
+}
+
+// Extracts the current goroutine and stack.
+// The stack starts with the caller of `GetStack`.
+func GetStack() (GoRoutine, []Frame) {
+	pcs := make([]uintptr, maxStackDepth)
+	// Skip runtime.Callers and GetStack
+	n := runtime.Callers(2, pcs)
+	return currentGoRoutine(), framesFromPCs(pcs[:n])
+}
+
+func framesFromPCs(pcs []uintptr) []Frame {
+	frames := runtime.CallersFrames(pcs)
+	stack := make([]Frame, 0, len(pcs))
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" || frame.File != "" {
+			stack = append(stack, Frame{
+				File:     relativeFile(frame.Function, frame.File),
+				Line:     strconv.Itoa(frame.Line),
+				Function: frame.Function,
+			})
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+// Makes `file` relative to its module by prefixing the file name with the package path of `function`,
+// e.g. `github.com/gin-gonic/gin/context.go` instead of an absolute path on the build machine.
+func relativeFile(function string, file string) string {
+	if function == "" {
+		return file
+	}
+
+	// The runtime escapes dots in the last element of the package path, e.g. `gopkg.in/yaml%2ev3.(*decoder).unmarshal`,
+	// so the first dot after the last slash starts the function or receiver name
+	pkg := function
+	slash := strings.LastIndex(pkg, "/")
+	if dot := strings.Index(pkg[slash+1:], "."); dot >= 0 {
+		pkg = pkg[:slash+1+dot]
+	}
+	pkg = strings.ReplaceAll(pkg, "%2e", ".")
+
+	if pkg == "main" {
+		return path.Base(file)
+	}
+	return pkg + "/" + path.Base(file)
+}
+
+// Returns the header of the current goroutine's stack dump, e.g. `goroutine 7 [running]`.
+func currentGoRoutine() GoRoutine {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	header, _, _ := strings.Cut(string(buf), "\n")
+	return strings.TrimSuffix(header, ":")
+}
@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetStack_StartsWithCaller(t *testing.T) {
+	goroutine, stack := GetStack()
+
+	assert.True(t, strings.HasPrefix(goroutine, "goroutine "))
+	assert.Equal(t, "github.com/oslokommune/common-lib-go/logging.TestGetStack_StartsWithCaller", stack[0].Function)
+	assert.Equal(t, "github.com/oslokommune/common-lib-go/logging/stacktrace_test.go", stack[0].File)
+}
+
+func panicking() {
+	var m map[string]int
+	m["crash"] = 1
+}
+
+func recoverStackTrace(f func()) (stacktrace StackTrace) {
+	defer func() {
+		r := recover()
+		goroutine, stack := GetStack()
+		stacktrace = StackTrace{GoRoutine: goroutine, Stack: stack, Reason: r}.SkipFramesAfterPanic()
+	}()
+	f()
+	return
+}
+
+func TestSkipFramesAfterPanic_StartsAtPanickingFunction(t *testing.T) {
+	stacktrace := recoverStackTrace(panicking)
+
+	assert.Equal(t, "github.com/oslokommune/common-lib-go/logging.panicking", stacktrace.Stack[0].Function)
+	assert.Equal(t, "github.com/oslokommune/common-lib-go/logging/stacktrace_test.go", stacktrace.Stack[0].File)
+}
+
+func TestStackTraceMarshaller_FiltersInternalFrames(t *testing.T) {
+	stacktrace := recoverStackTrace(panicking)
+
+	out := StackTraceMarshaller(stacktrace).([]map[string]string)
+
+	for _, frame := range out {
+		assert.False(t, strings.HasPrefix(frame["function"], "runtime."), frame["function"])
+		assert.False(t, strings.HasPrefix(frame["function"], "testing."), frame["function"])
+	}
+}
+
+func TestStackTraceMarshaller_HandlesWrappedStackTrace(t *testing.T) {
+	stacktrace := recoverStackTrace(panicking)
+
+	out := StackTraceMarshaller(fmt.Errorf("wrapped: %w", stacktrace)).([]map[string]string)
+
+	assert.Equal(t, "github.com/oslokommune/common-lib-go/logging.panicking", out[0]["function"])
+}
+
+func TestStackTraceMarshaller_HandlesPkgErrors(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", pkgerrors.New("failed"))
+
+	out := StackTraceMarshaller(err).([]map[string]string)
+
+	assert.Equal(t, "github.com/oslokommune/common-lib-go/logging.TestStackTraceMarshaller_HandlesPkgErrors", out[0]["function"])
+}
+
+func TestStackTraceMarshaller_ReturnsNil_WhenErrorHasNoStack(t *testing.T) {
+	assert.Nil(t, StackTraceMarshaller(errors.New("failed")))
+}
+
+func TestStackTrace_ErrorIncludesReason(t *testing.T) {
+	stacktrace := StackTrace{GoRoutine: "goroutine 7 [running]", Reason: "boom"}
+
+	assert.Equal(t, "panic in goroutine 7 [running]: boom", stacktrace.Error())
+}
+
+func TestRelativeFile_UsesPackagePath(t *testing.T) {
+	cases := map[string][2]string{
+		"github.com/gin-gonic/gin/context.go": {"github.com/gin-gonic/gin.(*Context).Next", "/go/pkg/mod/github.com/gin-gonic/gin@v1.10.0/context.go"},
+		"gopkg.in/yaml.v3/decode.go":          {"gopkg.in/yaml%2ev3.(*decoder).unmarshal", "/go/pkg/mod/gopkg.in/yaml.v3@v3.0.1/decode.go"},
+		"gopkg.in/yaml.v3/yaml.go":            {"gopkg.in/yaml%2ev3.Unmarshal", "/go/pkg/mod/gopkg.in/yaml.v3@v3.0.1/yaml.go"},
+		"net/http/server.go":                  {"net/http.HandlerFunc.ServeHTTP", "/usr/local/go/src/net/http/server.go"},
+		"main.go":                             {"main.main.func1", "/src/app/main.go"},
+	}
+
+	for expected, frame := range cases {
+		assert.Equal(t, expected, relativeFile(frame[0], frame[1]), frame[0])
+	}
+}